@@ -1,29 +1,129 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/sairash/gotots"
 )
 
 func main() {
 	dir := flag.String("dir", "", "input directory containing Go files")
-	output := flag.String("output", "", "output TypeScript file path")
+	output := flag.String("output", "", "output TypeScript file path, - for stdout")
+	outDir := flag.String("outdir", "", "output directory, one TypeScript module is written per Go package")
+	zod := flag.Bool("zod", false, "generate zod schemas instead of interfaces")
+	tag := flag.String("tag", "json", "comma separated struct tag keys fields are named by, in order of preference")
+	buildTags := flag.String("tags", "", "comma separated build tags files are selected by, like go build -tags")
+	include := flag.String("include", "", "comma separated patterns of the types to generate: a type name regexp, pkg:<glob> or file:<glob>")
+	exclude := flag.String("exclude", "", "comma separated patterns of the types not to generate, like -include")
+	includeReferenced := flag.Bool("include-referenced", false, "also generate the types referred to by the included ones")
+	roots := flag.String("roots", "", "comma separated names of the types to generate along with every type they refer to")
+	check := flag.Bool("check", false, "fail with a diff when the output is out of date instead of writing it")
+	watch := flag.Bool("watch", false, "generate again whenever a go file changes, until interrupted")
+	collisions := flag.String("collisions", "error", "how types of different packages sharing a name are handled: error, prefix or namespace")
 	flag.Parse()
 
-	if *dir == "" || *output == "" {
-		fmt.Fprintf(os.Stderr, "Usage: gotots -dir <input_dir> -output <output_file>\n")
+	patterns := flag.Args()
+	if *dir == "" && len(patterns) == 0 || *output == "" && *outDir == "" {
+		fmt.Fprintf(os.Stderr, "Usage: gotots (-dir <input_dir> | <packages>) (-output <output_file> | -outdir <output_dir>)\n")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	err := gotots.New().FromDir(*dir).ToFile(*output).Generate()
+	g := gotots.New().FromDir(*dir).FromPackages(patterns...).WithTagKey(strings.Split(*tag, ",")...)
+	target := *output
+	// the generated code goes to stdout, the messages go to stderr then
+	status := os.Stdout
+	switch *output {
+	case "":
+	case "-":
+		if *check && *outDir == "" {
+			fmt.Fprintf(os.Stderr, "Error: -check compares the output with a file, it cannot check stdout\n")
+			os.Exit(1)
+		}
+		g = g.ToWriter(os.Stdout)
+		target = "stdout"
+		status = os.Stderr
+	default:
+		g = g.ToFile(*output)
+	}
+	if *outDir != "" {
+		g = g.ToDir(*outDir)
+		target = *outDir
+	}
+	switch *collisions {
+	case "error":
+	case "prefix":
+		g = g.WithCollisionStrategy(gotots.CollisionPrefix)
+	case "namespace":
+		g = g.WithCollisionStrategy(gotots.CollisionNamespace)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown collision strategy %q\n", *collisions)
+		os.Exit(1)
+	}
+	if *buildTags != "" {
+		g = g.WithBuildTags(strings.Split(*buildTags, ",")...)
+	}
+	if *include != "" {
+		g = g.Include(strings.Split(*include, ",")...)
+	}
+	if *exclude != "" {
+		g = g.Exclude(strings.Split(*exclude, ",")...)
+	}
+	g = g.WithIncludeReferenced(*includeReferenced)
+	if *roots != "" {
+		g = g.Roots(strings.Split(*roots, ",")...)
+	}
+	if *zod {
+		g = g.WithOutputMode(gotots.OutputZod)
+	}
+
+	sources := patterns
+	if *dir != "" {
+		sources = append([]string{*dir}, patterns...)
+	}
+	printGenerated := func() {
+		fmt.Fprintf(status, "Generated %s from %s\n", target, strings.Join(sources, " "))
+		for _, reference := range g.References() {
+			fmt.Fprintf(status, "  %s\n", reference)
+		}
+	}
+
+	if *check {
+		if err := g.Verify(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(status, "%s is up to date\n", target)
+		return
+	}
+
+	if *watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := g.Watch(ctx, func(err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return
+			}
+			printGenerated()
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	err := g.Generate()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Printf("Generated %s from %s\n", *output, *dir)
+	printGenerated()
 }
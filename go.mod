@@ -1,3 +1,14 @@
 module github.com/sairash/gotots
 
 go 1.24.4
+
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	golang.org/x/tools v0.42.0
+)
+
+require (
+	golang.org/x/mod v0.33.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
+)
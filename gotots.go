@@ -1,6 +1,58 @@
 package gotots
 
-import "github.com/sairash/gotots/internal"
+import (
+	"context"
+	"io"
+	"io/fs"
+
+	"github.com/sairash/gotots/internal"
+)
+
+type StringEnumStyle = internal.StringEnumStyle
+
+const (
+	StringEnumUnion = internal.StringEnumUnion
+	StringEnumEnum  = internal.StringEnumEnum
+	StringEnumConst = internal.StringEnumConst
+)
+
+type EmbedStyle = internal.EmbedStyle
+
+const (
+	EmbedFlatten = internal.EmbedFlatten
+	EmbedExtends = internal.EmbedExtends
+)
+
+type OutputMode = internal.OutputMode
+
+const (
+	OutputTypeScript = internal.OutputTypeScript
+	OutputZod        = internal.OutputZod
+)
+
+type CollisionStrategy = internal.CollisionStrategy
+
+const (
+	CollisionError     = internal.CollisionError
+	CollisionPrefix    = internal.CollisionPrefix
+	CollisionNamespace = internal.CollisionNamespace
+)
+
+type Reference = internal.Reference
+
+type TSType = internal.TSType
+
+type TypeMapper = internal.TypeMapper
+
+type TypeMapperFunc = internal.TypeMapperFunc
+
+type Field = internal.Field
+
+type StructTag = internal.StructTag
+
+type FieldMapper = internal.FieldMapper
+
+type FieldMapperFunc = internal.FieldMapperFunc
 
 type Generator struct {
 	gen *internal.Generator
@@ -17,11 +69,132 @@ func (g *Generator) FromDir(dir string) *Generator {
 	return g
 }
 
+func (g *Generator) FromFS(fsys fs.FS, root string) *Generator {
+	g.gen.FromFS(fsys, root)
+	return g
+}
+
+func (g *Generator) FromSource(filename, src string) *Generator {
+	g.gen.FromSource(filename, src)
+	return g
+}
+
+func (g *Generator) FromPackages(patterns ...string) *Generator {
+	g.gen.FromPackages(patterns...)
+	return g
+}
+
 func (g *Generator) ToFile(file string) *Generator {
 	g.gen.ToFile(file)
 	return g
 }
 
+func (g *Generator) ToWriter(w io.Writer) *Generator {
+	g.gen.ToWriter(w)
+	return g
+}
+
+func (g *Generator) ToDir(dir string) *Generator {
+	g.gen.ToDir(dir)
+	return g
+}
+
+func (g *Generator) ToJSONSchema(file string) *Generator {
+	g.gen.ToJSONSchema(file)
+	return g
+}
+
+func (g *Generator) WithStringEnumStyle(style StringEnumStyle) *Generator {
+	g.gen.WithStringEnumStyle(style)
+	return g
+}
+
+func (g *Generator) WithEmbedStyle(style EmbedStyle) *Generator {
+	g.gen.WithEmbedStyle(style)
+	return g
+}
+
+func (g *Generator) WithTagKey(keys ...string) *Generator {
+	g.gen.WithTagKey(keys...)
+	return g
+}
+
+func (g *Generator) WithBuildTags(tags ...string) *Generator {
+	g.gen.WithBuildTags(tags...)
+	return g
+}
+
+func (g *Generator) WithJSONSemantics(enabled bool) *Generator {
+	g.gen.WithJSONSemantics(enabled)
+	return g
+}
+
+func (g *Generator) WithTypeMapping(goType, tsType string) *Generator {
+	g.gen.WithTypeMapping(goType, tsType)
+	return g
+}
+
+func (g *Generator) WithTypeMappingFrom(goType, tsType, module string) *Generator {
+	g.gen.WithTypeMappingFrom(goType, tsType, module)
+	return g
+}
+
+func (g *Generator) WithTypeMapper(mapper TypeMapper) *Generator {
+	g.gen.WithTypeMapper(mapper)
+	return g
+}
+
+func (g *Generator) WithFieldMapper(mapper FieldMapper) *Generator {
+	g.gen.WithFieldMapper(mapper)
+	return g
+}
+
+func (g *Generator) WithCollisionStrategy(strategy CollisionStrategy) *Generator {
+	g.gen.WithCollisionStrategy(strategy)
+	return g
+}
+
+func (g *Generator) WithOutputMode(mode OutputMode) *Generator {
+	g.gen.WithOutputMode(mode)
+	return g
+}
+
+func (g *Generator) Include(patterns ...string) *Generator {
+	g.gen.Include(patterns...)
+	return g
+}
+
+func (g *Generator) Exclude(patterns ...string) *Generator {
+	g.gen.Exclude(patterns...)
+	return g
+}
+
+func (g *Generator) WithIncludeReferenced(enabled bool) *Generator {
+	g.gen.WithIncludeReferenced(enabled)
+	return g
+}
+
+func (g *Generator) Roots(names ...string) *Generator {
+	g.gen.Roots(names...)
+	return g
+}
+
+func (g *Generator) References() []Reference {
+	return g.gen.References()
+}
+
 func (g *Generator) Generate() error {
 	return g.gen.Generate()
 }
+
+func (g *Generator) GenerateString() (string, error) {
+	return g.gen.GenerateString()
+}
+
+func (g *Generator) Verify() error {
+	return g.gen.Verify()
+}
+
+func (g *Generator) Watch(ctx context.Context, report func(err error)) error {
+	return g.gen.Watch(ctx, report)
+}
@@ -1,10 +1,16 @@
 package gotots
 
 import (
+	"context"
+	"encoding/json"
+	"go/types"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -646,7 +652,26 @@ type Secret struct {
 	if !strings.Contains(output, "public_key: string") {
 		t.Error("Output should contain 'public_key' field")
 	}
-	// Fields with json:"-" should use the original field name (not be skipped entirely)
+	// Fields with json:"-" are never serialised by encoding/json
+	if strings.Contains(output, "PrivateKey") {
+		t.Error("Field with json:\"-\" should be skipped")
+	}
+	if strings.Contains(output, "Internal") {
+		t.Error("Field with json:\"-\" should be skipped")
+	}
+
+	// Without JSON semantics they keep their original field name
+	err = New().FromDir(tmpDir).ToFile(outputFile).WithJSONSemantics(false).Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	content, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	output = string(content)
 	if !strings.Contains(output, "PrivateKey: string") {
 		t.Error("Field with json:\"-\" should use original field name 'PrivateKey'")
 	}
@@ -717,6 +742,37 @@ func runTestGenerator(t *testing.T, fileName, goContent string) string {
 	return string(content)
 }
 
+// Helper to generate from several files, keyed by their path relative to the
+// input directory, configure may set extra options on the generator
+func runTestGeneratorFiles(t *testing.T, files map[string]string, configure func(g *Generator) *Generator) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "output.ts")
+	g := New().FromDir(tmpDir).ToFile(outputFile)
+	if configure != nil {
+		g = configure(g)
+	}
+	if err := g.Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	return string(content)
+}
+
 func TestGenerateErrors(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -863,3 +919,2334 @@ func TestGenerateFileHandling(t *testing.T) {
 		t.Error("Should not have exported Internal from test file")
 	}
 }
+
+func TestGenerateEnums(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Iota Block",
+			`package models
+			type Status int
+			const (
+				Active Status = iota
+				Inactive
+				Banned
+			)
+			type User struct { Status Status }`,
+			[]string{
+				"export enum Status {",
+				"Active = 0,",
+				"Inactive = 1,",
+				"Banned = 2,",
+				"Status: Status;",
+			},
+			nil,
+		},
+		{
+			"Skipped Values and Expressions",
+			`package models
+			type Permission uint
+			const (
+				_ Permission = 1 << iota
+				Read
+				Write
+				Execute
+			)
+			type File struct {
+				Perms []Permission
+				Owner *Permission
+			}`,
+			[]string{
+				"Read = 2,",
+				"Write = 4,",
+				"Execute = 8,",
+				"Perms: Permission[];",
+				"Owner?: Permission | null;",
+			},
+			[]string{"_ ="},
+		},
+		{
+			"Single Typed Constants",
+			`package models
+			type Level int
+			const Low Level = iota
+			const High Level = 10
+			const Mid = Level(5)`,
+			[]string{"Low = 0,", "High = 10,", "Mid = 5,"},
+			nil,
+		},
+		{
+			"Untyped Constants Are Ignored",
+			`package models
+			const (
+				A = iota
+				B
+			)
+			type Data struct { Count int }`,
+			nil,
+			[]string{"export enum"},
+		},
+	})
+}
+
+// describes the strings expected in, or absent from, the output for a go source
+type outputCase struct {
+	name           string
+	goSource       string
+	mustContain    []string
+	mustNotContain []string
+}
+
+func runOutputCases(t *testing.T, tests []outputCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runTestGenerator(t, "model.go", tt.goSource)
+			for _, search := range tt.mustContain {
+				if !strings.Contains(output, search) {
+					t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+				}
+			}
+			for _, avoid := range tt.mustNotContain {
+				if strings.Contains(output, avoid) {
+					t.Errorf("Output contains unexpected string %q\nFull Output:\n%s", avoid, output)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateStringEnums(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"String Union",
+			`package models
+			type Role string
+			const (
+				Admin  Role = "admin"
+				Editor Role = "editor"
+			)
+			type User struct {
+				Role  Role   ` + "`json:\"role\"`" + `
+				Roles []Role ` + "`json:\"roles\"`" + `
+			}`,
+			[]string{
+				`export type Role = "admin" | "editor";`,
+				"role: Role;",
+				"roles: Role[];",
+			},
+			[]string{"export enum Role"},
+		},
+		{
+			"String Type Without Constants",
+			`package models
+			type Name string
+			const Default = "x"`,
+			nil,
+			[]string{"export type Name", "export enum"},
+		},
+		{
+			"Aliased Values",
+			`package models
+			type Role string
+			const (
+				Admin  Role = "admin"
+				Editor Role = "editor"
+				Root        = Admin
+			)`,
+			[]string{`export type Role = "admin" | "editor";`},
+			nil,
+		},
+		{
+			"Escaped Values",
+			`package models
+			type Quote string
+			const Double Quote = "say \"hi\""`,
+			[]string{`export type Quote = "say \"hi\"";`},
+			nil,
+		},
+	})
+}
+
+func TestGenerateStringEnumStyles(t *testing.T) {
+	source := `package models
+
+type Role string
+
+const (
+	Admin  Role = "admin"
+	Editor Role = "editor"
+	Root        = Admin
+)
+`
+	tests := []struct {
+		name        string
+		style       StringEnumStyle
+		mustContain []string
+	}{
+		{
+			"Union",
+			StringEnumUnion,
+			[]string{`export type Role = "admin" | "editor";`},
+		},
+		{
+			"Enum",
+			StringEnumEnum,
+			[]string{"export enum Role {", `Admin = "admin",`, `Editor = "editor",`, `Root = "admin",`},
+		},
+		{
+			"Const Object",
+			StringEnumConst,
+			[]string{
+				"export const Role = {",
+				`Admin: "admin",`,
+				`Root: "admin",`,
+				"} as const;",
+				"export type Role = typeof Role[keyof typeof Role];",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, "model.go"), []byte(source), 0644); err != nil {
+				t.Fatal(err)
+			}
+			outputFile := filepath.Join(tmpDir, "types.ts")
+			err := New().FromDir(tmpDir).ToFile(outputFile).WithStringEnumStyle(tt.style).Generate()
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			content, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			for _, search := range tt.mustContain {
+				if !strings.Contains(string(content), search) {
+					t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateEmbeddedStructs(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Promoted Fields",
+			`package models
+			type User struct {
+				ID   int    ` + "`json:\"id\"`" + `
+				Name string ` + "`json:\"name\"`" + `
+			}
+			type Admin struct {
+				User
+				Level int ` + "`json:\"level\"`" + `
+			}`,
+			[]string{"export interface Admin {\n\tid: number;\n\tname: string;\n\tlevel: number;\n};"},
+			nil,
+		},
+		{
+			"Pointer Embedding Makes Fields Optional",
+			`package models
+			type Audit struct { CreatedBy string }
+			type Post struct {
+				*Audit
+				Title string
+			}`,
+			[]string{"export interface Post {\n\tCreatedBy?: string;\n\tTitle: string;\n};"},
+			nil,
+		},
+		{
+			"Shallower Field Wins",
+			`package models
+			type Base struct { ID string; Kind string }
+			type Item struct {
+				Base
+				ID int
+			}`,
+			[]string{"export interface Item {\n\tKind: string;\n\tID: number;\n};"},
+			nil,
+		},
+		{
+			"Tagged Field Wins At Same Depth",
+			`package models
+			type A struct { Title int ` + "`json:\"Name\"`" + ` }
+			type B struct { Name string }
+			type C struct { A; B }`,
+			[]string{"export interface C {\n\tName: number;\n};"},
+			nil,
+		},
+		{
+			"Ambiguous Fields Are Dropped",
+			`package models
+			type A struct { Name string; X int }
+			type B struct { Name string; Y int }
+			type C struct { A; B }`,
+			[]string{"export interface C {\n\tX: number;\n\tY: number;\n};"},
+			nil,
+		},
+		{
+			"Tagged Embedded Struct Is A Field",
+			`package models
+			type Meta struct { Version int }
+			type Doc struct {
+				Meta ` + "`json:\"meta\"`" + `
+				Skip Meta ` + "`json:\"-\"`" + `
+			}`,
+			[]string{"meta: Meta;"},
+			nil,
+		},
+		{
+			"Ignored Embedded Struct",
+			`package models
+			type Meta struct { Version int }
+			type Doc struct {
+				Meta ` + "`json:\"-\"`" + `
+				Title string
+			}`,
+			[]string{"export interface Doc {\n\tTitle: string;\n};"},
+			nil,
+		},
+		{
+			"Embedded Non-Struct Types",
+			`package models
+			type Label string
+			type tag string
+			type Post struct {
+				Label
+				tag
+			}`,
+			[]string{"\tLabel: "},
+			[]string{"tag"},
+		},
+		{
+			"Unexported Embedded Struct Is Promoted",
+			`package models
+			type base struct { ID int }
+			type Thing struct { base }`,
+			[]string{"export interface Thing {\n\tID: number;\n};"},
+			nil,
+		},
+	})
+}
+
+func TestGenerateEmbeddedStructsAcrossPackages(t *testing.T) {
+	output := runTestGeneratorFiles(t, map[string]string{
+		"admin.go": `package models
+
+import "example.com/app/common"
+
+type Admin struct {
+	common.Base
+	Level int
+}
+`,
+		"common/base.go": `package common
+
+type Base struct {
+	ID        int
+	CreatedAt string
+}
+`,
+	}, nil)
+
+	if !strings.Contains(output, "export interface Admin {\n\tID: number;\n\tCreatedAt: string;\n\tLevel: number;\n};") {
+		t.Errorf("Embedded struct from another package should be promoted\nFull Output:\n%s", output)
+	}
+}
+
+func TestGenerateEmbeddedStructsExtends(t *testing.T) {
+	output := runTestGeneratorFiles(t, map[string]string{
+		"model.go": `package models
+
+type User struct {
+	Name string
+}
+
+type Audit struct {
+	CreatedBy string
+}
+
+type Admin struct {
+	User
+	*Audit
+	Level int
+}
+`,
+	}, func(g *Generator) *Generator {
+		return g.WithEmbedStyle(EmbedExtends)
+	})
+
+	if !strings.Contains(output, "export interface Admin extends User, Partial<Audit> {\n\tLevel: number;\n};") {
+		t.Errorf("Embedded structs should be extended\nFull Output:\n%s", output)
+	}
+}
+
+func TestGenerateResolvedTypes(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Composite Types",
+			`package models
+			type User struct { Name string }
+			type Data struct {
+				Pointers  []*User
+				PtrSlice  *[]User
+				Matrix    [][]int
+				Groups    map[string][]User
+				Fixed     [4]int
+				Lookup    map[int]*User
+				Raw       []byte
+				Events    chan string
+			}`,
+			[]string{
+				"Pointers: (User | null)[];",
+				"PtrSlice?: User[] | null;",
+				"Matrix: number[][];",
+				"Groups: Record<string, User[]>;",
+				"Fixed: number[];",
+				"Lookup: Record<number, User | null>;",
+				"Raw: string;",
+				"Events: unknown;",
+			},
+			nil,
+		},
+		{
+			"Named And Alias Types",
+			`package models
+			type Label string
+			type IDs []int64
+			type Attrs map[string]any
+			type Person struct { Name string }
+			type Human = Person
+			type Data struct {
+				Label Label
+				IDs   IDs
+				Attrs Attrs
+				Owner Human
+			}`,
+			[]string{
+				"Label: string;",
+				"IDs: number[];",
+				"Attrs: Record<string, any>;",
+				"Owner: Person;",
+			},
+			nil,
+		},
+		{
+			"Multiple Names Per Field",
+			`package models
+			type Point struct { X, Y float64 }`,
+			[]string{"X: number;", "Y: number;"},
+			nil,
+		},
+		{
+			"Unresolved Imports",
+			`package models
+			import (
+				"github.com/google/uuid"
+				"github.com/shopspring/decimal"
+				"github.com/acme/geo"
+			)
+			type Order struct {
+				ID       uuid.UUID
+				Total    decimal.Decimal
+				Location geo.Point
+			}`,
+			[]string{"ID: string;", "Total: string;", "Location: Point;"},
+			nil,
+		},
+		{
+			"Standard Library Types",
+			`package models
+			import (
+				"database/sql"
+				"encoding/json"
+				"time"
+			)
+			type Event struct {
+				At       time.Time
+				Every    time.Duration
+				Month    time.Month
+				Note     sql.NullString
+				Payload  json.RawMessage
+			}`,
+			[]string{
+				"At: string;",
+				"Every: number;",
+				"Month: number;",
+				"Note: string | null;",
+				"Payload: any;",
+			},
+			nil,
+		},
+	})
+}
+
+func TestGenerateResolvesModulePackages(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"common/base.go": `package common
+
+type Status string
+
+type Base struct {
+	ID     int64
+	Status Status
+}
+`,
+		"models/user.go": `package models
+
+import "example.com/app/common"
+
+type User struct {
+	common.Base
+	Owner common.Base
+	Tags  []common.Status
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputFile := filepath.Join(tmpDir, "types.ts")
+	if err := New().FromDir(filepath.Join(tmpDir, "models")).ToFile(outputFile).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	output := string(content)
+	for _, search := range []string{
+		"export interface User {\n\tID: number;\n\tStatus: string;\n\tOwner: {\n\t\tID: number;\n\t\tStatus: string;\n\t};\n\tTags: string[];\n};",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+	if strings.Contains(output, "export interface Base") {
+		t.Error("Types of packages outside of the input directory should not be exported")
+	}
+}
+
+func TestGenerateGenericStructs(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Type Parameters",
+			`package models
+			type Page[T any] struct {
+				Items []T
+				Total int
+			}`,
+			[]string{"export interface Page<T> {\n\tItems: T[];\n\tTotal: number;\n};"},
+			nil,
+		},
+		{
+			"Instantiations",
+			`package models
+			type User struct { Name string }
+			type Pair[K comparable, V any] struct { Key K; Value V }
+			type Page[T any] struct { Items []T }
+			type Response struct {
+				Users  Page[User]
+				Nested Page[Page[*User]]
+				Entry  Pair[string, []int]
+			}`,
+			[]string{
+				"export interface Pair<K, V> {",
+				"Users: Page<User>;",
+				"Nested: Page<Page<User | null>>;",
+				"Entry: Pair<string, number[]>;",
+			},
+			nil,
+		},
+		{
+			"Constraints",
+			`package models
+			type Number interface { ~int | ~int64 | ~float64 }
+			type Stats[N Number, S ~string] struct {
+				Sum  N
+				Name S
+			}`,
+			[]string{"export interface Stats<N extends number = number, S extends string = string> {"},
+			nil,
+		},
+		{
+			"Constraint Before Unconstrained",
+			`package models
+			type Stats[N ~int | ~float64, K comparable, S ~string] struct {
+				Sum  map[K]N
+				Name S
+			}`,
+			[]string{"export interface Stats<N extends number, K, S extends string = string> {"},
+			nil,
+		},
+		{
+			"Embedded Instantiation",
+			`package models
+			type User struct { Name string }
+			type Page[T any] struct { Items []T; Total int }
+			type UserPage struct {
+				Page[User]
+				Cursor string
+			}`,
+			[]string{"export interface UserPage {\n\tItems: User[];\n\tTotal: number;\n\tCursor: string;\n};"},
+			nil,
+		},
+	})
+}
+
+func TestGenerateJSONSemantics(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Dash Name",
+			`package models
+			type Row struct {
+				Dash string ` + "`json:\"-,\"`" + `
+				Kebab string ` + "`json:\"kebab-case\"`" + `
+			}`,
+			[]string{`"-": string;`, `"kebab-case": string;`},
+			nil,
+		},
+		{
+			"Unexported Fields Are Skipped",
+			`package models
+			type Account struct {
+				Email    string
+				password string
+				secret   string ` + "`json:\"secret\"`" + `
+			}`,
+			[]string{"export interface Account {\n\tEmail: string;\n};"},
+			nil,
+		},
+		{
+			"String Option",
+			`package models
+			type Stats struct {
+				Count   int64    ` + "`json:\"count,string\"`" + `
+				Ratio   float64  ` + "`json:\"ratio,string\"`" + `
+				Enabled bool     ` + "`json:\"enabled,string\"`" + `
+				Limit   *int     ` + "`json:\"limit,string\"`" + `
+				Name    string   ` + "`json:\"name,string\"`" + `
+				Tags    []int    ` + "`json:\"tags,string\"`" + `
+			}`,
+			[]string{
+				"count: string;",
+				"ratio: string;",
+				"enabled: string;",
+				"limit?: string | null;",
+				"name: string;",
+				"tags: number[];",
+			},
+			nil,
+		},
+	})
+}
+
+func TestGenerateWithoutJSONSemantics(t *testing.T) {
+	output := runTestGeneratorFiles(t, map[string]string{
+		"model.go": `package models
+
+type Account struct {
+	Email    string
+	password string
+	Count    int ` + "`json:\"count,string\"`" + `
+}
+`,
+	}, func(g *Generator) *Generator {
+		return g.WithJSONSemantics(false)
+	})
+
+	for _, search := range []string{"password: string;", "count: number;"} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+}
+
+func TestGenerateWithTypeMappings(t *testing.T) {
+	source := map[string]string{
+		"model.go": `package models
+
+import (
+	"github.com/guregu/null"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserID int64
+
+type Account struct {
+	ID      primitive.ObjectID
+	Owner   UserID
+	Balance pgtype.Numeric
+	Nick    null.String
+	History []pgtype.Numeric
+	Count   int64
+}
+`,
+	}
+
+	output := runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+		return g.
+			WithTypeMapping("github.com/jackc/pgx/v5/pgtype.Numeric", "string").
+			WithTypeMapping("null.String", "string | null").
+			WithTypeMapping("int64", "bigint").
+			WithTypeMappingFrom("go.mongodb.org/mongo-driver/bson/primitive.ObjectID", "ObjectId", "bson").
+			WithTypeMapper(TypeMapperFunc(func(goType types.Type) (TSType, bool) {
+				if named, ok := goType.(*types.Named); ok && named.Obj().Name() == "UserID" {
+					return TSType{Type: "number"}, true
+				}
+				return TSType{}, false
+			})).
+			WithTypeMapper(TypeMapperFunc(func(goType types.Type) (TSType, bool) {
+				if named, ok := goType.(*types.Named); ok && named.Obj().Name() == "UserID" {
+					return TSType{Type: "Branded<string, \"UserID\">", From: "./brand"}, true
+				}
+				return TSType{}, false
+			})).
+			// registered last, but int64 is mapped with WithTypeMapping
+			WithTypeMapper(TypeMapperFunc(func(goType types.Type) (TSType, bool) {
+				if basic, ok := goType.(*types.Basic); ok && basic.Kind() == types.Int64 {
+					return TSType{Type: "number"}, true
+				}
+				return TSType{}, false
+			}))
+	})
+
+	for _, search := range []string{
+		"import type { Branded } from \"./brand\";\nimport type { ObjectId } from \"bson\";\n",
+		"ID: ObjectId;",
+		"Owner: Branded<string, \"UserID\">;",
+		"Balance: string;",
+		"Nick: string | null;",
+		"History: string[];",
+		"Count: bigint;",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+}
+
+func TestGenerateWithFieldMapper(t *testing.T) {
+	source := map[string]string{
+		"model.go": `package models
+
+import "time"
+
+type Event struct {
+	ID        int64     ` + "`json:\"id\" db:\"id\"`" + `
+	StartsAt  time.Time ` + "`json:\"startsAt\" db:\"starts_at,timestamptz\"`" + `
+	Location  string    ` + "`json:\"location\" db:\"location,point\"`" + `
+	Organizer string    ` + "`json:\"organizer\" yaml:\"host\"`" + `
+}
+`,
+	}
+	mapper := FieldMapperFunc(func(field Field) (TSType, bool) {
+		switch {
+		case field.Tags["db"].HasOption("timestamptz"):
+			return TSType{Type: "Date"}, true
+		case field.Tags["db"].HasOption("point"):
+			return TSType{Type: "Point", From: "./geo"}, true
+		case field.Tags["yaml"].Name != "":
+			return TSType{Type: "string | null"}, true
+		}
+		return TSType{}, false
+	})
+
+	output := runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+		return g.WithFieldMapper(mapper)
+	})
+	for _, search := range []string{
+		"import type { Point } from \"./geo\";\n",
+		"\tid: number;",
+		"\tstartsAt: Date;",
+		"\tlocation: Point;",
+		"\torganizer: string | null;",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+
+	output = runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+		return g.WithFieldMapper(mapper).WithOutputMode(OutputZod)
+	})
+	for _, search := range []string{
+		"import type { Point } from \"./geo\";\n",
+		"\tstartsAt: z.custom<Date>(),",
+		"\tlocation: z.custom<Point>(),",
+		"\torganizer: z.string().nullable(),",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+}
+
+func TestGenerateMarshalers(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Text Marshaler",
+			`package models
+			type Point struct { X, Y int }
+			func (p Point) MarshalText() ([]byte, error) { return nil, nil }
+			type Shape struct {
+				Center Point
+				Path   []Point
+			}`,
+			[]string{
+				"export type Point = string;",
+				"Center: Point;",
+				"Path: Point[];",
+			},
+			[]string{"export interface Point"},
+		},
+		{
+			"JSON Marshaler With Pointer Receiver",
+			`package models
+			type Blob struct { data []byte }
+			func (b *Blob) MarshalJSON() ([]byte, error) { return nil, nil }
+			func (b *Blob) MarshalText() ([]byte, error) { return nil, nil }`,
+			[]string{"export type Blob = unknown;"},
+			nil,
+		},
+		{
+			"Marshaling Enum",
+			`package models
+			type Level int
+			const (
+				Debug Level = iota
+				Info
+			)
+			func (l Level) MarshalText() ([]byte, error) { return nil, nil }`,
+			[]string{"export type Level = string;"},
+			[]string{"export enum Level"},
+		},
+		{
+			"Types Without Declarations",
+			`package models
+			import "net/netip"
+			type Flag bool
+			func (f Flag) MarshalText() ([]byte, error) { return nil, nil }
+			type Host struct {
+				Addr netip.Addr
+				Flag Flag
+			}`,
+			[]string{"Addr: string;", "Flag: string;"},
+			nil,
+		},
+		{
+			"Wrong Signature",
+			`package models
+			type Money struct { Cents int }
+			func (m Money) MarshalText() string { return "" }`,
+			[]string{"export interface Money {"},
+			nil,
+		},
+		{
+			"Type Directive",
+			`package models
+			// Amount is written as a decimal string or a number.
+			//gotots:type string | number
+			type Amount struct { cents int64 }
+			func (a Amount) MarshalJSON() ([]byte, error) { return nil, nil }
+			type (
+				//gotots:type Record<string, string>
+				Labels []string
+			)
+			type Order struct {
+				Total  Amount
+				Labels Labels
+			}`,
+			[]string{
+				"export type Amount = string | number;",
+				"Total: Amount;",
+				"Labels: Record<string, string>;",
+			},
+			nil,
+		},
+	})
+}
+
+func TestGenerateDirectives(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Field Directives",
+			`package models
+			type User struct {
+				ID       int64  ` + "`json:\"id\"`" + ` //gotots:readonly
+				//gotots:ignore
+				Password string ` + "`json:\"password\"`" + `
+				//gotots:type ` + "`user-${number}`" + `
+				Handle   string ` + "`json:\"handle\"`" + `
+				//gotots:name displayName
+				Name     string
+				//gotots:optional
+				Bio      string ` + "`json:\"bio\"`" + `
+				//gotots:required
+				Avatar   *string ` + "`json:\"avatar,omitempty\"`" + `
+			}`,
+			[]string{"export interface User {\n" +
+				"\treadonly id: number;\n" +
+				"\thandle: `user-${number}`;\n" +
+				"\tdisplayName: string;\n" +
+				"\tbio?: string;\n" +
+				"\tavatar: string | null;\n" +
+				"};"},
+			[]string{"password"},
+		},
+		{
+			"Type Directives",
+			`package models
+			//gotots:ignore
+			type internalState struct { Counter int }
+
+			// Account is renamed for the frontend.
+			//gotots:name AccountDTO
+			//gotots:readonly
+			type Account struct {
+				ID    int
+				State internalState
+			}
+
+			//gotots:name Tier
+			type Plan string
+			const (
+				Free Plan = "free"
+				Pro  Plan = "pro"
+			)
+
+			type Owner struct {
+				Account Account
+				Plan    Plan
+			}`,
+			[]string{
+				"export interface AccountDTO {\n\treadonly ID: number;\n\treadonly State: {\n\t\tCounter: number;\n\t};\n};",
+				`export type Tier = "free" | "pro";`,
+				"Account: AccountDTO;",
+				"Plan: Tier;",
+			},
+			[]string{"interface internalState", "interface Account "},
+		},
+		{
+			"Ignored Enum",
+			`package models
+			type Plan string
+			const (
+				Free Plan = "free"
+			)
+			//gotots:ignore
+			type Level int
+			const (
+				Low Level = iota
+			)
+			type Owner struct { Level Level }`,
+			[]string{"Level: number;"},
+			[]string{"enum Level"},
+		},
+	})
+}
+
+func TestGenerateDocComments(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Struct And Field Docs",
+			`package models
+
+// User is an account holder.
+//gotots:readonly
+type User struct {
+	// ID is the primary key.
+	ID int ` + "`json:\"id\"`" + `
+
+	Email string ` + "`json:\"email\"`" + ` // verified address
+
+	// Nick is shown instead of the name.
+	//
+	// Deprecated: use DisplayName instead.
+	Nick string ` + "`json:\"nick\"`" + `
+
+	// Note can hold */ safely.
+	Note string ` + "`json:\"note\"`" + `
+}`,
+			[]string{
+				"/** User is an account holder. */\nexport interface User {",
+				"\t/** ID is the primary key. */\n\treadonly id: number;",
+				"\t/** verified address */\n\treadonly email: string;",
+				"\t/**\n\t * Nick is shown instead of the name.\n\t * @deprecated use DisplayName instead.\n\t */\n\treadonly nick: string;",
+				"/** Note can hold *\\/ safely. */",
+			},
+			[]string{"gotots:"},
+		},
+		{
+			"Enum Docs",
+			`package models
+
+// Level of a log line.
+type Level int
+
+const (
+	// Debug is verbose.
+	Debug Level = iota
+	Info // the default
+)`,
+			[]string{
+				"/** Level of a log line. */\nexport enum Level {",
+				"\t/** Debug is verbose. */\n\tDebug = 0,",
+				"\t/** the default */\n\tInfo = 1,",
+			},
+			nil,
+		},
+		{
+			"Deprecated Type",
+			`package models
+
+// Deprecated: use V2.
+type Legacy struct { ID int }`,
+			[]string{"/** @deprecated use V2. */\nexport interface Legacy {"},
+			nil,
+		},
+	})
+}
+
+func TestGenerateZod(t *testing.T) {
+	output := runTestGeneratorFiles(t, map[string]string{
+		"model.go": `package models
+
+import "time"
+
+type Role string
+
+const (
+	Admin  Role = "admin"
+	Editor Role = "editor"
+	Root        = Admin
+)
+
+type Level int
+
+const (
+	Low Level = iota
+	High
+)
+
+type Page[T any] struct {
+	Items []T
+	Total int
+}
+
+// User is an account holder.
+type User struct {
+	ID       int64             ` + "`json:\"id\"`" + `
+	Name     *string           ` + "`json:\"name\"`" + `
+	Email    string            ` + "`json:\"email,omitempty\"`" + `
+	Role     Role              ` + "`json:\"role\"`" + `
+	Level    Level             ` + "`json:\"level\"`" + `
+	Tags     []string          ` + "`json:\"tags\"`" + `
+	Meta     map[string]int    ` + "`json:\"meta\"`" + `
+	Address  struct {
+		City string ` + "`json:\"city\"`" + `
+	} ` + "`json:\"address\"`" + `
+	Created  time.Time         ` + "`json:\"created\"`" + `
+	Manager  *User             ` + "`json:\"manager\"`" + `
+	Team     *Team             ` + "`json:\"team\"`" + `
+	Count    int64             ` + "`json:\"count,string\"`" + `
+}
+
+type Team struct {
+	Members Page[User] ` + "`json:\"members\"`" + `
+}
+
+type Tag struct {
+	ID      int //gotots:readonly
+	Label   string
+	ByLevel map[Level]string
+}
+
+type List[T any] struct {
+	Value T
+	Next  *List[T]
+}
+`,
+	}, func(g *Generator) *Generator {
+		return g.WithOutputMode(OutputZod)
+	})
+
+	for _, search := range []string{
+		"import { z } from \"zod\";\n",
+		"export const RoleSchema = z.enum([\"admin\", \"editor\"]);\nexport type Role = z.infer<typeof RoleSchema>;",
+		"export enum Level {",
+		"export const LevelSchema = z.nativeEnum(Level);",
+		"export interface Page<T> {",
+		"export const PageSchema = <T extends z.ZodTypeAny>(T: T) => z.object({\n\tItems: z.array(T),\n\tTotal: z.number(),\n});",
+		"/** User is an account holder. */\nexport interface User {",
+		"export const UserSchema: z.ZodType<User> = z.object({\n",
+		"\tid: z.number(),\n",
+		"\tname: z.string().nullable().optional(),\n",
+		"\temail: z.string().optional(),\n",
+		"\trole: RoleSchema,\n",
+		"\tlevel: LevelSchema,\n",
+		"\ttags: z.array(z.string()),\n",
+		"\tmeta: z.record(z.string(), z.number()),\n",
+		"\taddress: z.object({\n\t\tcity: z.string(),\n\t}),\n",
+		"\tcreated: z.string(),\n",
+		"\tmanager: z.lazy(() => UserSchema).nullable().optional(),\n",
+		"\tteam: z.lazy(() => TeamSchema).nullable().optional(),\n",
+		"\tcount: z.string(),\n",
+		"export const TagSchema = z.object({\n\tID: z.number().readonly(),\n\tLabel: z.string(),\n\tByLevel: z.record(z.coerce.number(), z.string()),\n});\nexport type Tag = z.infer<typeof TagSchema>;",
+		"\tmembers: PageSchema(UserSchema),\n",
+		"export const ListSchema = <T extends z.ZodTypeAny>(T: T): z.ZodType<List<z.infer<T>>> => z.object({\n\tValue: T,\n\tNext: z.lazy(() => ListSchema(T)).nullable().optional(),\n});",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+	if strings.Contains(output, "export interface Tag") {
+		t.Error("Zod output should infer the types of structs that are not recursive")
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := `package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	Admin  Role = "admin"
+	Editor Role = "editor"
+	Root        = Admin
+)
+
+// User is an account holder.
+type User struct {
+	ID      uuid.UUID         ` + "`json:\"id\"`" + `
+	Name    *string           ` + "`json:\"name\"`" + `
+	Email   string            ` + "`json:\"email,omitempty\"`" + `
+	Age     int               ` + "`json:\"age\"`" + `
+	Role    Role              ` + "`json:\"role\"`" + `
+	Created time.Time         ` + "`json:\"created\"`" + `
+	Scores  map[string]float64 ` + "`json:\"scores\"`" + `
+	Friends []User            ` + "`json:\"friends\"`" + `
+	Manager *User             ` + "`json:\"manager,omitempty\"`" + `
+	Avatar  []byte            ` + "`json:\"avatar\"`" + `
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "model.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := New().FromDir(tmpDir).ToJSONSchema(schemaFile).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	content, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v\n%s", err, content)
+	}
+	if document["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("Unexpected $schema %v", document["$schema"])
+	}
+
+	for _, search := range []string{
+		`"Role": {
+      "type": "string",
+      "enum": [
+        "admin",
+        "editor"
+      ]
+    }`,
+		`"description": "User is an account holder."`,
+		`"id": {
+          "type": "string",
+          "format": "uuid"
+        }`,
+		`"name": {
+          "type": [
+            "string",
+            "null"
+          ]
+        }`,
+		`"age": {
+          "type": "integer"
+        }`,
+		`"role": {
+          "$ref": "#/$defs/Role"
+        }`,
+		`"created": {
+          "type": "string",
+          "format": "date-time"
+        }`,
+		`"scores": {
+          "type": "object",
+          "additionalProperties": {
+            "type": "number"
+          }
+        }`,
+		`"friends": {
+          "type": "array",
+          "items": {
+            "$ref": "#/$defs/User"
+          }
+        }`,
+		`"manager": {
+          "anyOf": [
+            {
+              "$ref": "#/$defs/User"
+            },
+            {
+              "type": "null"
+            }
+          ]
+        }`,
+		`"avatar": {
+          "type": "string",
+          "contentEncoding": "base64"
+        }`,
+		`"required": [
+        "id",
+        "age",
+        "role",
+        "created",
+        "scores",
+        "friends",
+        "avatar"
+      ]`,
+	} {
+		if !strings.Contains(string(content), search) {
+			t.Errorf("Schema missing expected string %q\nFull Schema:\n%s", search, content)
+		}
+	}
+}
+
+func TestGenerateValidateTags(t *testing.T) {
+	source := map[string]string{
+		"model.go": `package models
+
+type Signup struct {
+	Name     string   ` + "`json:\"name\" validate:\"required,min=3,max=50\"`" + `
+	Email    string   ` + "`json:\"email,omitempty\" validate:\"required,email\"`" + `
+	Plan     string   ` + "`json:\"plan\" validate:\"oneof=free pro 'team plus'\"`" + `
+	Seats    *int     ` + "`json:\"seats\" validate:\"omitempty,oneof=1 5 10\"`" + `
+	Age      int      ` + "`json:\"age\" validate:\"gte=18,lte=130\"`" + `
+	Tags     []string ` + "`json:\"tags\" validate:\"max=5,dive,max=10\"`" + `
+	Website  string   ` + "`json:\"website\" validate:\"url|email\"`" + `
+}
+`,
+	}
+
+	output := runTestGeneratorFiles(t, source, nil)
+	for _, search := range []string{
+		"name: string;",
+		"email: string;",
+		`plan: "free" | "pro" | "team plus";`,
+		"seats?: 1 | 5 | 10 | null;",
+		"website: string;",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+
+	zod := runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+		return g.WithOutputMode(OutputZod)
+	})
+	for _, search := range []string{
+		"name: z.string().min(3).max(50),",
+		"email: z.string().email(),",
+		`plan: z.enum(["free", "pro", "team plus"]),`,
+		"seats: z.union([z.literal(1), z.literal(5), z.literal(10)]).nullable().optional(),",
+		"age: z.number().min(18).max(130),",
+		"tags: z.array(z.string()).max(5),",
+		"website: z.string(),",
+	} {
+		if !strings.Contains(zod, search) {
+			t.Errorf("Zod output missing expected string %q\nFull Output:\n%s", search, zod)
+		}
+	}
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+		return g.ToJSONSchema(schemaFile)
+	})
+	schema, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	var document struct {
+		Defs map[string]struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(schema, &document); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	signup := document.Defs["Signup"]
+	checks := []struct {
+		property string
+		keyword  string
+		want     any
+	}{
+		{"name", "minLength", 3.0},
+		{"name", "maxLength", 50.0},
+		{"email", "format", "email"},
+		{"age", "minimum", 18.0},
+		{"age", "maximum", 130.0},
+		{"tags", "maxItems", 5.0},
+	}
+	for _, check := range checks {
+		if got := signup.Properties[check.property][check.keyword]; got != check.want {
+			t.Errorf("%s.%s = %v, want %v", check.property, check.keyword, got, check.want)
+		}
+	}
+	if plan := signup.Properties["plan"]["enum"]; len(plan.([]any)) != 3 {
+		t.Errorf("plan.enum = %v, want 3 values", plan)
+	}
+	if !strings.Contains(strings.Join(signup.Required, ","), "email") {
+		t.Errorf("validate:\"required\" should make email required, got %v", signup.Required)
+	}
+}
+
+func TestGenerateStructTagParsing(t *testing.T) {
+	runOutputCases(t, []outputCase{
+		{
+			"Separators",
+			"package models\n" +
+				"type Row struct {\n" +
+				"\tSpaces string `db:\"spaces\"   json:\"spaces,omitempty\"`\n" +
+				"\tTabs   string `db:\"tabs\"\tjson:\"tabs\"`\n" +
+				"\tQuoted string `validate:\"oneof='a b' c\" json:\"quoted\"`\n" +
+				"}",
+			[]string{"spaces?: string;", "tabs: string;", `quoted: "a b" | "c";`},
+			nil,
+		},
+		{
+			"Option Names",
+			"package models\n" +
+				"type Row struct {\n" +
+				"\tFoo    string `json:\"omitemptyFoo\"`\n" +
+				"\tBar    string `json:\"bar,stringly\"`\n" +
+				"\tBaz    int    `json:\"baz,omitempty,string\"`\n" +
+				"\tMasked string `jsonx:\"masked,omitempty\"`\n" +
+				"}",
+			[]string{"omitemptyFoo: string;", "bar: string;", "baz?: string;", "Masked: string;"},
+			[]string{"omitemptyFoo?", "masked"},
+		},
+		{
+			"Malformed Tag",
+			"package models\n" +
+				"type Row struct {\n" +
+				"\tName string `json:name db:\"name\"`\n" +
+				"}",
+			[]string{"Name: string;"},
+			nil,
+		},
+	})
+}
+
+func TestGenerateWithTagKey(t *testing.T) {
+	source := map[string]string{
+		"model.go": `package models
+
+type Document struct {
+	ID      string ` + "`bson:\"_id,omitempty\" json:\"id\"`" + `
+	Title   string ` + "`json:\"title\"`" + `
+	Secret  string ` + "`bson:\"-\" json:\"secret\"`" + `
+	Author  string
+}
+`,
+	}
+
+	tests := []struct {
+		name        string
+		keys        []string
+		mustContain []string
+	}{
+		{
+			"Default",
+			nil,
+			[]string{"\tid: string;", "\ttitle: string;", "\tsecret: string;", "\tAuthor: string;"},
+		},
+		{
+			"Single Key",
+			[]string{"bson"},
+			[]string{"\t_id?: string;", "\ttitle: string;", "\tauthor: string;"},
+		},
+		{
+			"Fallback",
+			[]string{"form", "json"},
+			[]string{"\tid: string;", "\ttitle: string;", "\tAuthor: string;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runTestGeneratorFiles(t, source, func(g *Generator) *Generator {
+				return g.WithTagKey(tt.keys...)
+			})
+			for _, search := range tt.mustContain {
+				if !strings.Contains(output, search) {
+					t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+				}
+			}
+			if len(tt.keys) > 0 && tt.keys[0] == "bson" && strings.Contains(output, "secret") {
+				t.Errorf("Field tagged bson:\"-\" should be skipped\nFull Output:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestGenerateToDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"models/user.go": `package models
+
+import "example.com/app/models/billing"
+
+type User struct {
+	ID      int
+	Plan    billing.Plan
+	Invoice *billing.User
+}
+`,
+		"models/billing/plan.go": `package billing
+
+type Tier string
+
+const Pro Tier = "pro"
+
+type Plan struct {
+	Tier Tier
+}
+
+type User struct {
+	Account string
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outDir := filepath.Join(tmpDir, "ts")
+	if err := New().FromDir(filepath.Join(tmpDir, "models")).ToDir(outDir).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := map[string][]string{
+		"models.ts": {
+			"import type { Plan, User as BillingUser } from \"./billing\";\n",
+			"export interface User {\n\tID: number;\n\tPlan: Plan;\n\tInvoice?: BillingUser | null;\n};",
+		},
+		"billing.ts": {
+			`export type Tier = "pro";`,
+			"export interface Plan {\n\tTier: Tier;\n};",
+			"export interface User {\n\tAccount: string;\n};",
+		},
+		// both packages declare User
+		"index.ts": {
+			"export * as billing from \"./billing\";\nexport * as models from \"./models\";\n",
+		},
+	}
+	for file, searches := range expected {
+		content, err := os.ReadFile(filepath.Join(outDir, file))
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", file, err)
+			continue
+		}
+		for _, search := range searches {
+			if !strings.Contains(string(content), search) {
+				t.Errorf("%s missing expected string %q\nFull Output:\n%s", file, search, content)
+			}
+		}
+		if file == "billing.ts" && strings.Contains(string(content), "import") {
+			t.Errorf("billing.ts should not import anything\nFull Output:\n%s", content)
+		}
+	}
+}
+
+func TestGenerateNameCollisions(t *testing.T) {
+	files := map[string]string{
+		"billing/account.go": `package billing
+
+type Account struct {
+	Balance int
+}
+`,
+		"auth/account.go": `package auth
+
+type Account struct {
+	Login string
+}
+
+type Session struct {
+	Account Account
+}
+`,
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		for name, content := range files {
+			path := filepath.Join(tmpDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := New().FromDir(tmpDir).ToFile(filepath.Join(tmpDir, "types.ts")).Generate()
+		if err == nil {
+			t.Fatal("Expected an error for colliding type names")
+		}
+		for _, search := range []string{"Account is declared in", "account.go:3:6"} {
+			if !strings.Contains(err.Error(), search) {
+				t.Errorf("Error %q should contain %q", err, search)
+			}
+		}
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		output := runTestGeneratorFiles(t, files, func(g *Generator) *Generator {
+			return g.WithCollisionStrategy(CollisionPrefix)
+		})
+		for _, search := range []string{
+			"export interface AuthAccount {\n\tLogin: string;\n};",
+			"export interface BillingAccount {\n\tBalance: number;\n};",
+			"export interface Session {\n\tAccount: AuthAccount;\n};",
+		} {
+			if !strings.Contains(output, search) {
+				t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+			}
+		}
+	})
+
+	t.Run("Namespace", func(t *testing.T) {
+		output := runTestGeneratorFiles(t, files, func(g *Generator) *Generator {
+			return g.WithCollisionStrategy(CollisionNamespace)
+		})
+		for _, search := range []string{
+			"export namespace auth {\n\texport interface Account {\n\t\tLogin: string;\n\t};\n\n\texport interface Session {\n\t\tAccount: auth.Account;\n\t};\n}\n",
+			"export namespace billing {\n\texport interface Account {\n\t\tBalance: number;\n\t};\n}\n",
+		} {
+			if !strings.Contains(output, search) {
+				t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+			}
+		}
+	})
+
+	t.Run("Namespace Zod", func(t *testing.T) {
+		// namespaces are written after the declarations that use them, their
+		// schemas are not defined before the module has loaded
+		output := runTestGeneratorFiles(t, map[string]string{
+			"go.mod": "module example.com/app\n\ngo 1.22\n",
+			"auth/kind.go": `package auth
+
+type Kind string
+
+const Admin Kind = "admin"
+
+type Account struct {
+	Login string
+}
+`,
+			"billing/kind.go": `package billing
+
+type Kind string
+
+const Invoice Kind = "invoice"
+
+type Account struct {
+	Balance int
+}
+`,
+			"api/req.go": `package api
+
+import "example.com/app/billing"
+
+type Req struct {
+	K       billing.Kind
+	Account billing.Account
+}
+`,
+		}, func(g *Generator) *Generator {
+			return g.WithCollisionStrategy(CollisionNamespace).WithOutputMode(OutputZod)
+		})
+		for _, search := range []string{
+			"export const ReqSchema = z.object({\n\tK: z.lazy(() => billing.KindSchema),\n\tAccount: z.lazy(() => billing.AccountSchema),\n});",
+			"export namespace billing {\n\texport const KindSchema = z.enum([\"invoice\"]);",
+		} {
+			if !strings.Contains(output, search) {
+				t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+			}
+		}
+		if strings.Index(output, "ReqSchema") > strings.Index(output, "export namespace billing") {
+			t.Errorf("Expected the namespaces after the other declarations\nFull Output:\n%s", output)
+		}
+	})
+}
+
+func TestGenerateFromPackages(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.22\n\nrequire example.com/lib v0.0.0\n\nreplace example.com/lib => ./lib\n",
+		"lib/go.mod": "module example.com/lib\n\ngo 1.22\n",
+		"lib/money.go": `package lib
+
+type Money struct {
+	Amount   int64
+	Currency string
+}
+`,
+		"models/event.go": `package models
+
+import (
+	"net/url"
+	"time"
+
+	"example.com/lib"
+)
+
+type Event struct {
+	At    time.Time
+	Link  url.URL
+	Price lib.Money
+}
+`,
+		"common/status.go": `package common
+
+type Status string
+
+const Active Status = "active"
+`,
+		"models/user.go": `package models
+
+import "example.com/app/common"
+
+type User struct {
+	Name   string
+	Status common.Status
+}
+`,
+		"models/user_test.go": `package models
+
+type Fixture struct{ Name string }
+`,
+		"models/admin/admin.go": `package admin
+
+type Admin struct {
+	Level int
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(tmpDir)
+
+	outputFile := filepath.Join(t.TempDir(), "types.ts")
+	err := New().FromPackages("./models/...", "example.com/app/common").ToFile(outputFile).Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	output := string(content)
+	for _, search := range []string{
+		`export type Status = "active";`,
+		"export interface User {\n\tName: string;\n\tStatus: Status;\n};",
+		"export interface Admin {\n\tLevel: number;\n};",
+		"\tAt: string;",
+		"\tPrice: {\n\t\tAmount: number;\n\t\tCurrency: string;\n\t};",
+	} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+	if strings.Contains(output, "Fixture") {
+		t.Errorf("Test files should not be loaded\nFull Output:\n%s", output)
+	}
+
+	err = New().FromPackages("example.com/app/missing").ToFile(outputFile).Generate()
+	if err == nil {
+		t.Error("Expected an error for a package that does not exist")
+	}
+}
+
+func TestGenerateBuildConstraints(t *testing.T) {
+	files := map[string]string{
+		"model.go":                              "package models\n\ntype User struct {\n\tName string\n}\n",
+		"gen.go":                                "//go:build ignore\n\npackage main\n\ntype Generator struct {\n\tOut string\n}\n",
+		"tools.go":                              "//go:build tools\n\npackage models\n\ntype Tools struct {\n\tVersion string\n}\n",
+		"platform_" + runtime.GOOS + ".go":      "package models\n\ntype Platform struct {\n\tNative bool\n}\n",
+		"platform_other.go":                     "//go:build !" + runtime.GOOS + "\n\npackage models\n\ntype Platform struct {\n\tOther bool\n}\n",
+		"testdata/fixture.go":                   "package testdata\n\ntype Fixture struct {\n\tData string\n}\n",
+		"vendor/example.com/lib/lib.go":         "package lib\n\ntype Vendored struct {\n\tData string\n}\n",
+		"_old/old.go":                           "package old\n\ntype Old struct {\n\tData string\n}\n",
+		".cache/cache.go":                       "package cache\n\ntype Cached struct {\n\tData string\n}\n",
+		"nested/" + runtime.GOARCH + "/arch.go": "package arch\n\ntype Arch struct {\n\tData string\n}\n",
+	}
+
+	tests := []struct {
+		name           string
+		tags           []string
+		mustContain    []string
+		mustNotContain []string
+	}{
+		{
+			"Default",
+			nil,
+			[]string{"export interface User", "export interface Platform", "Native: boolean;", "export interface Arch"},
+			[]string{"Generator", "Tools", "Other", "Fixture", "Vendored", "Old", "Cached"},
+		},
+		{
+			"Tags",
+			[]string{"tools"},
+			[]string{"export interface User", "export interface Tools", "Native: boolean;"},
+			[]string{"Generator", "Other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runTestGeneratorFiles(t, files, func(g *Generator) *Generator {
+				return g.WithBuildTags(tt.tags...)
+			})
+			for _, search := range tt.mustContain {
+				if !strings.Contains(output, search) {
+					t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+				}
+			}
+			for _, avoid := range tt.mustNotContain {
+				if strings.Contains(output, avoid) {
+					t.Errorf("Output contains unexpected string %q\nFull Output:\n%s", avoid, output)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateIncludeExclude(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"api/order.go": `package api
+
+import "example.com/app/models"
+
+type CreateOrderRequest struct {
+	Items  []models.Item
+	Status models.Status
+}
+
+type OrderResponse struct {
+	Order *models.Order
+}
+
+type HealthCheck struct {
+	OK bool
+}
+`,
+		"models/order.go": `package models
+
+type Status string
+
+const Open Status = "open"
+
+type Order struct {
+	ID       int
+	Items    []Item
+	Customer Customer
+}
+
+type Item struct {
+	SKU string
+}
+
+type Customer struct {
+	Name string
+}
+`,
+		"models/order_rows.go": `package models
+
+type OrderRow struct {
+	ID int
+}
+`,
+	}
+
+	tests := []struct {
+		name           string
+		configure      func(g *Generator) *Generator
+		mustContain    []string
+		mustNotContain []string
+	}{
+		{
+			"Type Name",
+			func(g *Generator) *Generator {
+				return g.Include("Request$")
+			},
+			[]string{"export interface CreateOrderRequest", "\tItems: {\n\t\tSKU: string;\n\t}[];", "\tStatus: string;"},
+			[]string{"OrderResponse", "HealthCheck", "export interface Item", "export type Status", "OrderRow"},
+		},
+		{
+			"Referenced Types",
+			func(g *Generator) *Generator {
+				return g.Include("Request$").WithIncludeReferenced(true)
+			},
+			[]string{"export interface CreateOrderRequest", "export interface Item", `export type Status = "open";`, "\tItems: Item[];", "\tStatus: Status;"},
+			[]string{"OrderResponse", "export interface Order ", "Customer"},
+		},
+		{
+			"Package And File",
+			func(g *Generator) *Generator {
+				return g.Include("pkg:example.com/app/models/...").Exclude("file:models/*_rows.go")
+			},
+			[]string{"export interface Order ", "export interface Item", "export interface Customer", "export type Status"},
+			[]string{"OrderRow", "CreateOrderRequest", "HealthCheck"},
+		},
+		{
+			"Excluded Reference",
+			func(g *Generator) *Generator {
+				return g.Include("Response$").Exclude("^Customer$").WithIncludeReferenced(true)
+			},
+			[]string{"export interface OrderResponse", "export interface Order ", "export interface Item", "\tCustomer: {\n\t\tName: string;\n\t};"},
+			[]string{"export interface Customer", "CreateOrderRequest", "export type Status"},
+		},
+		{
+			"Exclude Only",
+			func(g *Generator) *Generator {
+				return g.Exclude("Row$", "pkg:example.com/app/api")
+			},
+			[]string{"export interface Order ", "export interface Customer"},
+			[]string{"OrderRow", "CreateOrderRequest", "HealthCheck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runTestGeneratorFiles(t, files, tt.configure)
+			for _, search := range tt.mustContain {
+				if !strings.Contains(output, search) {
+					t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+				}
+			}
+			for _, avoid := range tt.mustNotContain {
+				if strings.Contains(output, avoid) {
+					t.Errorf("Output contains unexpected string %q\nFull Output:\n%s", avoid, output)
+				}
+			}
+		})
+	}
+
+	t.Run("Invalid Pattern", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "model.go"), []byte("package models\n\ntype User struct{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := New().FromDir(tmpDir).ToFile(filepath.Join(tmpDir, "types.ts")).Include("(").Generate()
+		if err == nil || !strings.Contains(err.Error(), `invalid pattern "("`) {
+			t.Errorf("Expected an invalid pattern error, got %v", err)
+		}
+	})
+}
+
+func TestGenerateRoots(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"api/order.go": `package api
+
+import "example.com/app/models"
+
+type CreateOrderRequest struct {
+	Items    []models.Item
+	Shipping *struct {
+		Address models.Address
+	}
+}
+
+type OrderResponse struct {
+	Orders map[string]*models.Order
+}
+
+type HealthCheck struct {
+	OK bool
+}
+`,
+		"models/order.go": `package models
+
+import "time"
+
+type Status string
+
+const Open Status = "open"
+
+type Order struct {
+	ID       int
+	Status   Status
+	Customer Customer
+	Placed   time.Time
+}
+
+type Item struct {
+	SKU string
+}
+
+type Customer struct {
+	Name   string
+	Orders []Order
+}
+
+type Address struct {
+	Street string
+}
+
+type OrderRow struct {
+	ID int
+}
+`,
+	}
+
+	var g *Generator
+	output := runTestGeneratorFiles(t, files, func(gen *Generator) *Generator {
+		g = gen.Roots("CreateOrderRequest", "api.OrderResponse")
+		return g
+	})
+
+	for _, search := range []string{"CreateOrderRequest", "OrderResponse", "export interface Item", "export interface Address", "export interface Order ", "export interface Customer", "export type Status"} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+	for _, avoid := range []string{"HealthCheck", "OrderRow"} {
+		if strings.Contains(output, avoid) {
+			t.Errorf("Output contains unexpected string %q\nFull Output:\n%s", avoid, output)
+		}
+	}
+
+	var references []string
+	for _, reference := range g.References() {
+		references = append(references, reference.String())
+	}
+	expected := []string{
+		"Status is referenced by Order.Status",
+		"CreateOrderRequest is a root",
+		"OrderResponse is a root",
+		"Order is referenced by OrderResponse.Orders",
+		"Item is referenced by CreateOrderRequest.Items",
+		"Customer is referenced by Order.Customer",
+		"Address is referenced by CreateOrderRequest.Shipping",
+	}
+	if strings.Join(references, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected references:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(references, "\n"))
+	}
+
+	t.Run("Unknown Root", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tmpDir, "model.go"), []byte("package models\n\ntype User struct{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := New().FromDir(tmpDir).ToFile(filepath.Join(tmpDir, "types.ts")).Roots("Account").Generate()
+		if err == nil || !strings.Contains(err.Error(), "root type Account not found") {
+			t.Errorf("Expected a root not found error, got %v", err)
+		}
+	})
+}
+
+func TestGenerateAgain(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "model.go")
+	if err := os.WriteFile(goFile, []byte("package models\n\ntype User struct {\n\tName string\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(goFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := New().FromDir(tmpDir)
+	if output, err := g.GenerateString(); err != nil || !strings.Contains(output, "Name: string;") {
+		t.Fatalf("GenerateString failed: %v\n%s", err, output)
+	}
+
+	// an edit of the same size within the granularity of modification times
+	if err := os.WriteFile(goFile, []byte("package models\n\ntype User struct {\n\tNick string\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(goFile, stat.ModTime(), stat.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	output, err := g.GenerateString()
+	if err != nil {
+		t.Fatalf("GenerateString failed: %v", err)
+	}
+	if !strings.Contains(output, "Nick: string;") || strings.Contains(output, "Name: string;") {
+		t.Errorf("Output not generated from the edited file\nFull Output:\n%s", output)
+	}
+}
+
+func TestGenerateWatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "model.go")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(goFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("package models\n\ntype User struct {\n\tName string\n}\n")
+
+	outputFile := filepath.Join(t.TempDir(), "types.ts")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// written by the watching goroutine before every result is reported
+	var stdout strings.Builder
+	results := make(chan error)
+	done := make(chan error)
+	go func() {
+		done <- New().FromDir(tmpDir).ToFile(outputFile).ToWriter(&stdout).Watch(ctx, func(err error) {
+			select {
+			case results <- err:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	next := func() error {
+		t.Helper()
+		select {
+		case err := <-results:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("Timed out waiting for a run")
+			return nil
+		}
+	}
+	readOutput := func() string {
+		t.Helper()
+		content, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		return string(content)
+	}
+
+	if err := next(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if output := readOutput(); !strings.Contains(output, "Name: string;") {
+		t.Errorf("Output missing the initial field\nFull Output:\n%s", output)
+	}
+
+	write("package models\n\ntype User struct {\n\tName string\n\tEmail string\n}\n")
+	if err := next(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if output := readOutput(); !strings.Contains(output, "Email: string;") {
+		t.Errorf("Output missing the added field\nFull Output:\n%s", output)
+	}
+
+	// a change that does not change the output leaves the file alone
+	stat, err := os.Stat(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := stat.ModTime().Add(-time.Hour)
+	if err := os.Chtimes(outputFile, past, past); err != nil {
+		t.Fatal(err)
+	}
+	written := stdout.Len()
+	write("package models\n\ntype User struct {\n\tName string\n\tEmail string\n}\n\nfunc helper() {}\n")
+	if err := next(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if stat, err := os.Stat(outputFile); err != nil || !stat.ModTime().Equal(past) {
+		t.Errorf("Output should not be rewritten when it did not change")
+	}
+	if stdout.Len() != written {
+		t.Errorf("Output should not be written to the writer again when it did not change")
+	}
+
+	// errors are reported and watching goes on
+	write("package models\n\ntype User struct {\n")
+	if err := next(); err == nil {
+		t.Error("Expected a parse error")
+	}
+	write("package models\n\ntype Account struct {\n\tID int\n}\n")
+	if err := next(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if output := readOutput(); !strings.Contains(output, "export interface Account") || strings.Contains(output, "User") {
+		t.Errorf("Output not regenerated after the error\nFull Output:\n%s", output)
+	}
+	if !strings.HasSuffix(stdout.String(), readOutput()) || stdout.Len() == written {
+		t.Errorf("Changed output not written to the writer\nFull Output:\n%s", stdout.String())
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch failed: %v", err)
+	}
+}
+
+func TestGenerateVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "model.go")
+	if err := os.WriteFile(goFile, []byte("package models\n\ntype User struct {\n\tName string\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	outputFile := filepath.Join(outputDir, "types.ts")
+	newGenerator := func() *Generator {
+		return New().FromDir(tmpDir).ToFile(outputFile)
+	}
+
+	err := newGenerator().Verify()
+	if err == nil || !strings.Contains(err.Error(), "+export interface User {") {
+		t.Errorf("Expected a diff against the missing output, got %v", err)
+	}
+	if _, err := os.Stat(outputFile); !os.IsNotExist(err) {
+		t.Error("Verify should not write the output")
+	}
+
+	if err := newGenerator().Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if err := newGenerator().Verify(); err != nil {
+		t.Errorf("Verify failed on fresh output: %v", err)
+	}
+
+	if err := os.WriteFile(goFile, []byte("package models\n\ntype User struct {\n\tName string\n\tEmail string\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = newGenerator().Verify()
+	if err == nil {
+		t.Fatal("Expected Verify to fail on stale output")
+	}
+	diff := "--- " + outputFile + "\n" +
+		"+++ " + outputFile + " (generated)\n" +
+		"@@ -2,4 +2,5 @@\n" +
+		" \n" +
+		" export interface User {\n" +
+		" \tName: string;\n" +
+		"+\tEmail: string;\n" +
+		" };"
+	if !strings.Contains(err.Error(), diff) {
+		t.Errorf("Expected diff:\n%s\ngot:\n%v", diff, err)
+	}
+
+	// a file that differs entirely is diffed without holding every step of the edit
+	stale := strings.Repeat("// stale\n", 20000)
+	if err := os.WriteFile(outputFile, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = newGenerator().Verify()
+	if err == nil || !strings.Contains(err.Error(), "@@ -1,20000 +1,") {
+		t.Errorf("Expected a diff of the rewritten file, got %.200v", err)
+	}
+
+	var sb strings.Builder
+	err = New().FromDir(tmpDir).ToWriter(&sb).Verify()
+	if err == nil || !strings.Contains(err.Error(), "nothing to verify") {
+		t.Errorf("Expected Verify to fail without an output file, got %v", err)
+	}
+	if sb.Len() > 0 {
+		t.Error("Verify should not write to the writer")
+	}
+}
+
+func TestGenerateInMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "model.go"), []byte("package models\n\ntype User struct {\n\tName string\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(t.TempDir(), "types.ts")
+	if err := New().FromDir(tmpDir).ToFile(outputFile).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	content, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := string(content)
+
+	t.Run("Writer", func(t *testing.T) {
+		var sb strings.Builder
+		if err := New().FromDir(tmpDir).ToWriter(&sb).Generate(); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		if sb.String() != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		output, err := New().FromDir(tmpDir).GenerateString()
+		if err != nil {
+			t.Fatalf("GenerateString failed: %v", err)
+		}
+		if output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("String Without Input", func(t *testing.T) {
+		_, err := New().GenerateString()
+		if err == nil || !strings.Contains(err.Error(), "input directory") {
+			t.Errorf("Expected error containing %q, got: %v", "input directory", err)
+		}
+	})
+}
+
+func TestGenerateFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/go.mod": {Data: []byte("module example.com/app\n\ngo 1.22\n")},
+		"api/models/user.go": {Data: []byte(`package models
+
+import "example.com/app/models/billing"
+
+type User struct {
+	ID   int          ` + "`json:\"id\"`" + `
+	Plan billing.Plan ` + "`json:\"plan\"`" + `
+}
+`)},
+		"api/models/billing/plan.go": {Data: []byte("package billing\n\ntype Plan struct {\n\tName string `json:\"name\"`\n}\n")},
+		"api/models/gen.go":          {Data: []byte("//go:build ignore\n\npackage main\n\ntype Generator struct{}\n")},
+		"api/models/testdata/x.go":   {Data: []byte("package testdata\n\ntype Fixture struct{}\n")},
+		"other/ignored.go":           {Data: []byte("package other\n\ntype Other struct{}\n")},
+	}
+
+	output, err := New().FromFS(fsys, "api/models").GenerateString()
+	if err != nil {
+		t.Fatalf("GenerateString failed: %v", err)
+	}
+	for _, search := range []string{"export interface User", "\tplan: Plan;", "export interface Plan", "\tname: string;"} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+	for _, avoid := range []string{"Generator", "Fixture", "Other"} {
+		if strings.Contains(output, avoid) {
+			t.Errorf("Output contains unexpected string %q\nFull Output:\n%s", avoid, output)
+		}
+	}
+}
+
+func TestGenerateFromSource(t *testing.T) {
+	output, err := New().
+		FromSource("go.mod", "module example.com/app\n").
+		FromSource("models/user.go", "package models\n\nimport \"example.com/app/common\"\n\ntype User struct {\n\tStatus common.Status\n}\n").
+		FromSource("common/status.go", "package common\n\ntype Status string\n\nconst Active Status = \"active\"\n").
+		GenerateString()
+	if err != nil {
+		t.Fatalf("GenerateString failed: %v", err)
+	}
+	for _, search := range []string{`export type Status = "active";`, "export interface User", "\tStatus: Status;"} {
+		if !strings.Contains(output, search) {
+			t.Errorf("Output missing expected string %q\nFull Output:\n%s", search, output)
+		}
+	}
+
+	t.Run("Syntax Error", func(t *testing.T) {
+		_, err := New().FromSource("user.go", "package models\n\ntype User struct {\n").GenerateString()
+		if err == nil || !strings.Contains(err.Error(), "user.go:3") {
+			t.Errorf("Expected a parse error with the file name, got %v", err)
+		}
+	})
+
+	t.Run("Invalid File Name", func(t *testing.T) {
+		_, err := New().FromSource("/tmp/user.go", "package models\n").GenerateString()
+		if err == nil || !strings.Contains(err.Error(), "invalid source file name") {
+			t.Errorf("Expected an invalid file name error, got %v", err)
+		}
+	})
+}
+
+func TestGenerateToDirSamePackageNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"url/link.go": `package url
+
+type Link struct {
+	Href string
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(tmpDir)
+
+	outDir := filepath.Join(t.TempDir(), "ts")
+	if err := New().FromPackages("./...", "net/url").ToDir(outDir).Generate(); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"example.com/app/url.ts": "export interface Link {",
+		"net/url.ts":             "export interface URL {",
+		"index.ts":               "export * from \"./example.com/app/url\";\nexport * from \"./net/url\";\n",
+	}
+	for file, search := range expected {
+		content, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", file, err)
+			continue
+		}
+		if !strings.Contains(string(content), search) {
+			t.Errorf("%s missing expected string %q\nFull Output:\n%s", file, search, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "url.ts")); !os.IsNotExist(err) {
+		t.Error("url.ts should not be written")
+	}
+}
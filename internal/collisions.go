@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// a declaration of a struct or enum, to find names declared by several packages
+type declaration struct {
+	name    *string
+	pkgPath string
+	pkgName string
+	pos     token.Pos
+}
+
+// finds structs and enums of different packages that share a name and
+// resolves them with the configured strategy. Writing one module per package
+// only collides in index.ts, which re-exports such modules as namespaces
+// unless the names are prefixed.
+func (g *Generator) resolveCollisions() error {
+	g.namespaces = make(map[string]string)
+
+	collisions := g.findCollisions()
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	switch g.collisionStrategy {
+	case CollisionPrefix:
+		for _, declarations := range collisions {
+			for _, decl := range declarations {
+				*decl.name = exportedName(decl.pkgName) + *decl.name
+			}
+		}
+		if collisions := g.findCollisions(); len(collisions) > 0 {
+			return g.collisionError(collisions)
+		}
+		g.parser.markEnumFields()
+		return nil
+
+	case CollisionError:
+		if g.outputDir == "" || g.outputFile != "" || g.outputWriter != nil || g.schemaFile != "" {
+			return g.collisionError(collisions)
+		}
+	}
+
+	packages := make(map[string]string)
+	for _, declarations := range collisions {
+		for _, decl := range declarations {
+			if pkgPath, ok := packages[decl.pkgName]; ok && pkgPath != decl.pkgPath {
+				return fmt.Errorf("packages %s and %s can not both be namespaced as %s", pkgPath, decl.pkgPath, decl.pkgName)
+			}
+			packages[decl.pkgName] = decl.pkgPath
+			g.namespaces[decl.pkgPath] = decl.pkgName
+		}
+	}
+	return nil
+}
+
+// returns the declarations of every name that is declared by more than one
+// package, in the order they were parsed
+func (g *Generator) findCollisions() [][]declaration {
+	var names []string
+	byName := make(map[string][]declaration)
+	add := func(decl declaration) {
+		if _, ok := byName[*decl.name]; !ok {
+			names = append(names, *decl.name)
+		}
+		byName[*decl.name] = append(byName[*decl.name], decl)
+	}
+
+	result := g.parser.parseResult
+	for i := range result.Enums {
+		enumInfo := &result.Enums[i]
+		add(declaration{&enumInfo.Name, enumInfo.PkgPath, enumInfo.Package, enumInfo.GoType.Obj().Pos()})
+	}
+	for i := range result.Structs {
+		structInfo := &result.Structs[i]
+		decl := declaration{name: &structInfo.Name, pkgPath: structInfo.PkgPath, pkgName: structInfo.Package}
+		if structInfo.GoType != nil {
+			decl.pos = structInfo.GoType.Obj().Pos()
+		}
+		add(decl)
+	}
+
+	var collisions [][]declaration
+	for _, name := range names {
+		declarations := byName[name]
+		for _, decl := range declarations[1:] {
+			if decl.pkgPath != declarations[0].pkgPath {
+				collisions = append(collisions, declarations)
+				break
+			}
+		}
+	}
+	return collisions
+}
+
+// reports the first collision with the positions of the declarations
+func (g *Generator) collisionError(collisions [][]declaration) error {
+	declarations := collisions[0]
+	first, second := declarations[0], declarations[1]
+	for _, decl := range declarations[1:] {
+		if decl.pkgPath != first.pkgPath {
+			second = decl
+			break
+		}
+	}
+	fset := g.parser.loader.fset
+	return fmt.Errorf("%s is declared in %s (%s) and %s (%s)",
+		*first.name, first.pkgPath, fset.Position(first.pos), second.pkgPath, fset.Position(second.pos))
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lines of context around the changes of a unified diff
+const diffContext = 3
+
+// a line of a diff: ' ' kept, '-' only in the old text, '+' only in the new one
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// returns a unified diff of the changes from old to new text of a file, or
+// "" when they are equal
+func unifiedDiff(file, oldText, newText string) string {
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	for start := 0; start < len(lines); {
+		// a hunk spans from the first change to the last change that is not
+		// further than twice the context from the one before
+		first := start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i <= last+2*diffContext; i++ {
+			if lines[i].kind != ' ' {
+				last = i
+			}
+		}
+
+		from := max(first-diffContext, 0)
+		to := min(last+diffContext+1, len(lines))
+		oldLine, newLine := 1, 1
+		for _, line := range lines[:from] {
+			if line.kind != '+' {
+				oldLine++
+			}
+			if line.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		// an empty range starts at the line before it
+		if oldCount == 0 {
+			oldLine--
+		}
+		if newCount == 0 {
+			newLine--
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s (generated)\n", file, file)
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, line := range lines[from:to] {
+			sb.WriteByte(line.kind)
+			sb.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+// splits a text into lines that keep their line break
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// returns the shortest edit from a to b, with the linear space variant of
+// Myers' algorithm, so that files that differ entirely do not run out of memory
+func diffLines(a, b []string) []diffLine {
+	return appendDiff(nil, a, b)
+}
+
+// appends the shortest edit from a to b to lines: the common prefix and
+// suffix are kept, the rest is split at a snake in the middle of the edit
+func appendDiff(lines []diffLine, a, b []string) []diffLine {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		lines = append(lines, diffLine{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			lines = append(lines, diffLine{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			lines = append(lines, diffLine{'-', line})
+		}
+	default:
+		// both are left and differ at their ends, so the edit takes at least
+		// two steps and both halves take fewer
+		x, y, u, v := middleSnake(a, b)
+		lines = appendDiff(lines, a[:x], b[:y])
+		for _, line := range a[x:u] {
+			lines = append(lines, diffLine{' ', line})
+		}
+		lines = appendDiff(lines, a[u:], b[v:])
+	}
+
+	for _, line := range common {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
+
+// returns the start x, y and end u, v of the snake in the middle of the
+// shortest edit from a to b, found by searching from both ends until the
+// paths overlap. Only the furthest x reached on every diagonal k = x - y is
+// kept, forward from the start and backward from the end.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			// the backward paths of the step before end on diagonal delta - k
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && u+backward[offset+c] >= n {
+				return x, y, u, v
+			}
+		}
+
+		// backward x and y count from the ends of a and b
+		for k := -d; k <= d; k += 2 {
+			var bx int
+			if k == -d || k != d && backward[offset+k-1] < backward[offset+k+1] {
+				bx = backward[offset+k+1]
+			} else {
+				bx = backward[offset+k-1] + 1
+			}
+			by := bx - k
+			ex, ey := bx, by
+			for ex < n && ey < m && a[n-1-ex] == b[m-1-ey] {
+				ex++
+				ey++
+			}
+			backward[offset+k] = ex
+			if c := delta - k; !odd && c >= -d && c <= d && forward[offset+c]+ex >= n {
+				return n - ex, m - ey, n - bx, m - by
+			}
+		}
+	}
+	// not reached, the paths overlap after half of the longest edit
+	return 0, 0, 0, 0
+}
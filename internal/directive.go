@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// prefix of the comment directives, e.g. //gotots:type string
+const directivePrefix = "//gotots:"
+
+// gotots directives by name, e.g. "type" for "//gotots:type string", with
+// the rest of the line as value
+type directives map[string]string
+
+// reports whether a directive is set
+func (d directives) has(name string) bool {
+	_, ok := d[name]
+	return ok
+}
+
+// returns the gotots directives of comment groups
+func parseDirectives(groups ...*ast.CommentGroup) directives {
+	result := make(directives)
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			rest, ok := strings.CutPrefix(comment.Text, directivePrefix)
+			if !ok {
+				continue
+			}
+			name, value, _ := strings.Cut(rest, " ")
+			result[name] = strings.TrimSpace(value)
+		}
+	}
+	return result
+}
+
+// applies the directives of a field: ignore, type, name, optional, required
+// and readonly. Returns false for ignored fields.
+func (d directives) applyField(fieldInfo *FieldInfo) bool {
+	if d.has("ignore") {
+		return false
+	}
+	if tsType := d["type"]; tsType != "" {
+		fieldInfo.TSType = tsType
+	}
+	if name := d["name"]; name != "" {
+		fieldInfo.JSONTag = name
+	}
+	if d.has("optional") {
+		fieldInfo.IsOptional = true
+	}
+	if d.has("required") {
+		fieldInfo.IsOptional = false
+	}
+	if d.has("readonly") {
+		fieldInfo.IsReadonly = true
+	}
+	return true
+}
+
+// returns the doc comment of a type declaration, which for a declaration
+// without parentheses is attached to the declaration rather than the spec
+func typeSpecDoc(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) *ast.CommentGroup {
+	if typeSpec.Doc == nil && !genDecl.Lparen.IsValid() {
+		return genDecl.Doc
+	}
+	return typeSpec.Doc
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// returns the text of comment groups without comment markers and directives
+func docText(groups ...*ast.CommentGroup) string {
+	var parts []string
+	for _, group := range groups {
+		if text := strings.TrimSpace(group.Text()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// formats a doc comment as a TSDoc block, "Deprecated:" paragraphs become
+// a trailing @deprecated tag
+func tsDoc(doc string, indentLevel int) string {
+	if doc == "" {
+		return ""
+	}
+
+	var lines, deprecated []string
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if rest, ok := strings.CutPrefix(paragraph, "Deprecated:"); ok {
+			deprecated = append(deprecated, strings.Split("@deprecated "+strings.TrimSpace(rest), "\n")...)
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(paragraph, "\n")...)
+	}
+	lines = append(lines, deprecated...)
+
+	indent := strings.Repeat("	", indentLevel)
+	if len(lines) == 1 {
+		return indent + "/** " + escapeDoc(lines[0]) + " */\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(indent+" * "+escapeDoc(line), " ") + "\n")
+	}
+	sb.WriteString(indent + " */\n")
+	return sb.String()
+}
+
+// keeps a comment line from ending the doc block
+func escapeDoc(line string) string {
+	return strings.ReplaceAll(line, "*/", "*\\/")
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"go/types"
+	"sort"
+	"unicode"
+)
+
+// a field reachable from a struct, possibly through embedded structs
+type promotedField struct {
+	field  FieldInfo
+	name   string
+	depth  int
+	tagged bool
+	index  []int
+}
+
+// a struct visited while promoting fields
+type embeddedLevel struct {
+	structInfo StructInfo
+	index      []int
+	optional   bool
+}
+
+// returns the fields of a struct as encoding/json sees them, with the fields
+// of embedded structs promoted into the parent. When two fields share a name
+// the shallowest one wins, ties are broken by a json tag and otherwise all of
+// them are dropped.
+func (g *Generator) promotedFields(structInfo StructInfo) []FieldInfo {
+	var candidates []promotedField
+
+	// structs seen at the current and next depth, a struct reached more than
+	// once at the same depth has its fields annihilated
+	var current []embeddedLevel
+	next := []embeddedLevel{{structInfo: structInfo}}
+	count := map[string]int{}
+	nextCount := map[string]int{}
+	visited := map[string]bool{}
+
+	for len(next) > 0 {
+		current, next = next, nil
+		count, nextCount = nextCount, map[string]int{}
+
+		for _, level := range current {
+			key := level.structInfo.PkgPath + "." + level.structInfo.Name
+			if len(level.index) > 0 {
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+			}
+
+			for i, field := range level.structInfo.Fields {
+				index := append(append([]int(nil), level.index...), i)
+				if level.optional {
+					field.IsOptional = true
+				}
+				if g.jsonSemantics && (field.IsIgnored || !field.IsEmbedded && !isExported(field.Name)) {
+					continue
+				}
+
+				if field.IsEmbedded && field.JSONTag == "" {
+					if embedded, ok := g.embeddedStruct(field); ok {
+						embeddedKey := embedded.PkgPath + "." + embedded.Name
+						nextCount[embeddedKey]++
+						if nextCount[embeddedKey] == 1 {
+							next = append(next, embeddedLevel{
+								structInfo: embedded,
+								index:      index,
+								optional:   level.optional || field.IsPointer,
+							})
+						}
+						continue
+					}
+
+					// embedded non-struct types are named after the type and
+					// ignored when unexported
+					if !isExported(field.Name) {
+						continue
+					}
+				}
+
+				name := field.Name
+				if field.JSONTag != "" {
+					name = field.JSONTag
+				}
+				candidate := promotedField{
+					field:  field,
+					name:   name,
+					depth:  len(index),
+					tagged: field.JSONTag != "",
+					index:  index,
+				}
+				candidates = append(candidates, candidate)
+				if count[key] > 1 {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	// keep the dominant field of every name
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].name != candidates[j].name {
+			return candidates[i].name < candidates[j].name
+		}
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+		return candidates[i].tagged && !candidates[j].tagged
+	})
+
+	var dominant []promotedField
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+		if field, ok := dominantField(candidates[i:j]); ok {
+			dominant = append(dominant, field)
+		}
+		i = j
+	}
+
+	// restore the declaration order
+	sort.Slice(dominant, func(i, j int) bool {
+		return indexLess(dominant[i].index, dominant[j].index)
+	})
+
+	fields := make([]FieldInfo, 0, len(dominant))
+	for _, candidate := range dominant {
+		fields = append(fields, candidate.field)
+	}
+	return fields
+}
+
+// returns the fields a struct declares itself and the interfaces it extends,
+// embedded structs that are generated and have no json tag are extended
+func (g *Generator) extendedFields(structInfo StructInfo) ([]FieldInfo, []string) {
+	var extends []string
+
+	own := structInfo
+	own.Fields = nil
+	for _, field := range structInfo.Fields {
+		if field.IsEmbedded && field.JSONTag == "" {
+			if _, ok := g.parser.lookupStruct(embeddedType(field)); ok {
+				tsType := g.typeToTS(embeddedType(field), 0)
+				if field.IsPointer {
+					tsType = "Partial<" + tsType + ">"
+				}
+				extends = append(extends, tsType)
+				continue
+			}
+		}
+		own.Fields = append(own.Fields, field)
+	}
+
+	return g.promotedFields(own), extends
+}
+
+// returns the fields to generate for a struct in the configured embed style
+func (g *Generator) structFields(structInfo StructInfo) []FieldInfo {
+	if g.embedStyle == EmbedExtends {
+		fields, _ := g.extendedFields(structInfo)
+		return fields
+	}
+	return g.promotedFields(structInfo)
+}
+
+// finds the struct of an embedded field, structs of packages that were not
+// scanned and instantiated generic structs are built from their type
+func (g *Generator) embeddedStruct(field FieldInfo) (StructInfo, bool) {
+	named, ok := embeddedType(field).(*types.Named)
+	if !ok {
+		return StructInfo{}, false
+	}
+
+	if structInfo, ok := g.parser.lookupStruct(named); ok && named.TypeArgs().Len() == 0 {
+		return *structInfo, true
+	}
+	obj := named.Obj()
+	if g.parser.loader.isStub(obj.Pkg()) {
+		return StructInfo{}, false
+	}
+	if structType, ok := named.Underlying().(*types.Struct); ok {
+		return *g.parser.typeStruct(structType, obj.Name(), obj.Pkg()), true
+	}
+	return StructInfo{}, false
+}
+
+// returns the type of an embedded field without its pointer
+func embeddedType(field FieldInfo) types.Type {
+	goType := types.Unalias(field.GoType)
+	if pointer, ok := goType.(*types.Pointer); ok {
+		goType = types.Unalias(pointer.Elem())
+	}
+	return goType
+}
+
+// picks the field that wins among fields sharing a name, sorted by depth
+func dominantField(fields []promotedField) (promotedField, bool) {
+	if len(fields) > 1 && fields[0].depth == fields[1].depth && fields[0].tagged == fields[1].tagged {
+		return promotedField{}, false
+	}
+	return fields[0], true
+}
+
+// compares two field index sequences
+func indexLess(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// reports whether a go identifier is exported
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package internal
+
+import (
+	"fmt"
+	"go/types"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// selects structs and enums by a pattern of Include or Exclude: "pkg:<glob>"
+// matches the import path, "file:<glob>" the file of the declaration and
+// anything else is a regular expression matched against the type name
+type typeFilter struct {
+	pattern string
+	kind    string // "pkg", "file" or "" for a type name
+	re      *regexp.Regexp
+}
+
+func parseTypeFilters(patterns []string) ([]typeFilter, error) {
+	filters := make([]typeFilter, 0, len(patterns))
+	for _, pattern := range patterns {
+		filter := typeFilter{pattern: pattern}
+		var err error
+		if kind, glob, ok := strings.Cut(pattern, ":"); ok && (kind == "pkg" || kind == "file") {
+			filter.kind = kind
+			filter.re, err = globRegexp(glob)
+		} else {
+			filter.re, err = regexp.Compile(pattern)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// reports whether the filter matches a type, name is the name it is
+// generated with, which may differ from the go name
+func (f typeFilter) matches(p *Parser, obj *types.TypeName, name string) bool {
+	switch f.kind {
+	case "pkg":
+		return obj.Pkg() != nil && f.re.MatchString(obj.Pkg().Path())
+	case "file":
+		file := filepath.ToSlash(p.loader.fset.Position(obj.Pos()).Filename)
+		if !strings.Contains(f.pattern, "/") {
+			return f.re.MatchString(path.Base(file))
+		}
+		// globs with a directory match the trailing elements of the path
+		for {
+			if f.re.MatchString(file) {
+				return true
+			}
+			_, rest, ok := strings.Cut(file, "/")
+			if !ok {
+				return false
+			}
+			file = rest
+		}
+	default:
+		return f.re.MatchString(obj.Name()) || f.re.MatchString(name)
+	}
+}
+
+// reports whether any of the filters matches a type
+func matchesAny(filters []typeFilter, p *Parser, obj *types.TypeName, name string) bool {
+	for _, filter := range filters {
+		if filter.matches(p, obj, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// compiles a glob to a regular expression: "*" and "?" do not match a
+// slash, "..." and "**" match anything and a trailing "/..." also matches
+// the path without it, like go package patterns do
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for rest := glob; rest != ""; {
+		switch {
+		case rest == "/...":
+			sb.WriteString("(/.*)?")
+			rest = ""
+		case strings.HasPrefix(rest, "..."):
+			sb.WriteString(".*")
+			rest = rest[3:]
+		case strings.HasPrefix(rest, "**"):
+			sb.WriteString(".*")
+			rest = rest[2:]
+		case rest[0] == '*':
+			sb.WriteString("[^/]*")
+			rest = rest[1:]
+		case rest[0] == '?':
+			sb.WriteString("[^/]")
+			rest = rest[1:]
+		default:
+			sb.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// drops the structs and enums that are not included, not reachable from a
+// root or are excluded. Types that are dropped are written like those of
+// packages that were not scanned, unless referenced types are included as well.
+func (g *Generator) filterTypes() error {
+	if len(g.includes) == 0 && len(g.excludes) == 0 && len(g.roots) == 0 {
+		return nil
+	}
+	includes, err := parseTypeFilters(g.includes)
+	if err != nil {
+		return err
+	}
+	excludes, err := parseTypeFilters(g.excludes)
+	if err != nil {
+		return err
+	}
+
+	p := g.parser
+	excluded := func(obj *types.TypeName, name string) bool {
+		return matchesAny(excludes, p, obj, name)
+	}
+
+	// without include patterns everything is included, unless there are roots
+	kept := make(map[*types.TypeName]bool)
+	var roots []*types.TypeName
+	p.eachDeclaration(func(obj *types.TypeName, name string) {
+		included := len(includes) == 0 && len(g.roots) == 0 || matchesAny(includes, p, obj, name)
+		if included && !excluded(obj, name) {
+			kept[obj] = true
+			roots = append(roots, obj)
+		}
+	})
+
+	rootTypes, err := g.rootTypes()
+	if err != nil {
+		return err
+	}
+	reached := p.references(rootTypes)
+	for obj := range reached {
+		if !excluded(obj, p.declaredName(obj)) {
+			kept[obj] = true
+		}
+	}
+
+	if g.includeReferenced {
+		for obj := range p.references(roots) {
+			if !excluded(obj, p.declaredName(obj)) {
+				kept[obj] = true
+			}
+		}
+	}
+
+	// the reached types that are kept, in the order they are generated
+	g.references = nil
+	p.eachDeclaration(func(obj *types.TypeName, name string) {
+		ref, ok := reached[obj]
+		if !ok || !kept[obj] {
+			return
+		}
+		reference := Reference{Name: name, Field: ref.field}
+		if obj.Pkg() != nil {
+			reference.PkgPath = obj.Pkg().Path()
+		}
+		if ref.from != nil {
+			reference.From = p.declaredName(ref.from)
+		}
+		g.references = append(g.references, reference)
+	})
+
+	p.filter(func(obj *types.TypeName) bool {
+		return kept[obj]
+	})
+	return nil
+}
+
+// returns the structs and enums named by Roots
+func (g *Generator) rootTypes() ([]*types.TypeName, error) {
+	var roots []*types.TypeName
+	for _, root := range g.roots {
+		found := false
+		g.parser.eachDeclaration(func(obj *types.TypeName, name string) {
+			if root == name || root == obj.Name() ||
+				obj.Pkg() != nil && (root == obj.Pkg().Name()+"."+obj.Name() || root == obj.Pkg().Path()+"."+obj.Name()) {
+				roots = append(roots, obj)
+				found = true
+			}
+		})
+		if !found {
+			return nil, fmt.Errorf("root type %s not found", root)
+		}
+	}
+	return roots, nil
+}
+
+// why a type was reached from the roots, the field of the struct that refers
+// to it, both are empty for the roots themselves
+type reference struct {
+	from  *types.TypeName
+	field string
+}
+
+// walks the fields of the roots through pointers, slices, maps, inline
+// structs and structs of packages that were not scanned, and returns every
+// parsed struct and enum that is reached
+func (p *Parser) references(roots []*types.TypeName) map[*types.TypeName]reference {
+	reached := make(map[*types.TypeName]reference)
+	expanded := make(map[*types.Named]bool)
+
+	var visit func(t types.Type, ref reference)
+	walkStruct := func(obj *types.TypeName) {
+		structInfo, ok := p.lookupStruct(obj.Type())
+		if !ok || p.isMarshaled(obj.Type().(*types.Named)) {
+			return
+		}
+		for _, field := range structInfo.Fields {
+			if field.IsIgnored || field.TSType != "" {
+				continue
+			}
+			visit(field.GoType, reference{obj, field.Name})
+		}
+	}
+	visit = func(t types.Type, ref reference) {
+		switch t := types.Unalias(t).(type) {
+		case *types.Pointer:
+			visit(t.Elem(), ref)
+		case *types.Slice:
+			visit(t.Elem(), ref)
+		case *types.Array:
+			visit(t.Elem(), ref)
+		case *types.Map:
+			visit(t.Key(), ref)
+			visit(t.Elem(), ref)
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				visit(t.Field(i).Type(), ref)
+			}
+		case *types.Named:
+			for i := 0; i < t.TypeArgs().Len(); i++ {
+				visit(t.TypeArgs().At(i), ref)
+			}
+			obj := t.Origin().Obj()
+			_, isStruct := p.structIndex[obj]
+			_, isEnum := p.enumIndex[obj]
+			if isStruct || isEnum {
+				if _, ok := reached[obj]; !ok {
+					reached[obj] = ref
+					walkStruct(obj)
+				}
+				return
+			}
+			if expanded[t] || p.isMarshaled(t) {
+				return
+			}
+			expanded[t] = true
+			visit(t.Underlying(), ref)
+		}
+	}
+
+	for _, root := range roots {
+		if _, ok := reached[root]; !ok {
+			reached[root] = reference{}
+			walkStruct(root)
+		}
+	}
+	return reached
+}
+
+// reports whether a type is not written from its layout
+func (p *Parser) isMarshaled(named *types.Named) bool {
+	return p.typeDirectives[named.Origin().Obj()]["type"] != "" || p.marshalerOf(named) != notMarshaler
+}
+
+// calls fn with the type and name of every parsed enum and struct
+func (p *Parser) eachDeclaration(fn func(obj *types.TypeName, name string)) {
+	for _, enumInfo := range p.parseResult.Enums {
+		fn(enumInfo.GoType.Obj(), enumInfo.Name)
+	}
+	for _, structInfo := range p.parseResult.Structs {
+		if structInfo.GoType != nil {
+			fn(structInfo.GoType.Obj(), structInfo.Name)
+		}
+	}
+}
+
+// returns the name a parsed enum or struct is generated with
+func (p *Parser) declaredName(obj *types.TypeName) string {
+	if structInfo, ok := p.lookupStruct(obj.Type()); ok {
+		return structInfo.Name
+	}
+	if enumInfo, ok := p.lookupEnum(obj.Type()); ok {
+		return enumInfo.Name
+	}
+	return obj.Name()
+}
+
+// keeps the parsed enums and structs that keep reports true for
+func (p *Parser) filter(keep func(obj *types.TypeName) bool) {
+	enums := p.parseResult.Enums[:0]
+	p.enumIndex = make(map[*types.TypeName]int)
+	for _, enumInfo := range p.parseResult.Enums {
+		if keep(enumInfo.GoType.Obj()) {
+			p.enumIndex[enumInfo.GoType.Obj()] = len(enums)
+			enums = append(enums, enumInfo)
+		}
+	}
+	p.parseResult.Enums = enums
+
+	structs := p.parseResult.Structs[:0]
+	p.structIndex = make(map[*types.TypeName]int)
+	for _, structInfo := range p.parseResult.Structs {
+		if structInfo.GoType == nil {
+			structs = append(structs, structInfo)
+			continue
+		}
+		if keep(structInfo.GoType.Obj()) {
+			p.structIndex[structInfo.GoType.Obj()] = len(structs)
+			structs = append(structs, structInfo)
+		}
+	}
+	p.parseResult.Structs = structs
+
+	p.markEnumFields()
+}
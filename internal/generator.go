@@ -1,22 +1,83 @@
 package internal
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"go/types"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"testing/fstest"
+	"unicode"
 )
 
 type Generator struct {
-	inputDir   string
-	outputFile string
-	parser     *Parser
+	inputDir        string
+	patterns        []string
+	fsys            fs.FS
+	fsRoot          string
+	sources         fstest.MapFS
+	outputFile      string
+	outputWriter    io.Writer
+	outputDir       string
+	schemaFile      string
+	stringEnumStyle StringEnumStyle
+	embedStyle      EmbedStyle
+	jsonSemantics   bool
+	outputMode      OutputMode
+
+	collisionStrategy CollisionStrategy
+	typeMappings      typeMappings
+	typeMappers       []TypeMapper
+	fieldMappers      []FieldMapper
+	parser            *Parser
+
+	// patterns of the structs and enums to generate, and whether the types
+	// they refer to are generated as well
+	includes          []string
+	excludes          []string
+	includeReferenced bool
+
+	// names of the types everything generated must be reachable from, and
+	// the types that were reached
+	roots      []string
+	references []Reference
+
+	// modules and names of the mapped types that need an import
+	imports map[string]map[string]bool
+
+	// namespaces of the packages whose declarations collide
+	namespaces map[string]string
+
+	// modules of the scanned packages when writing one module per package,
+	// and the module being generated
+	modules map[string]*tsModule
+	module  *tsModule
+
+	// named types being written out in place, to stop at recursive types
+	expanding map[*types.Named]bool
+
+	// structs and enums whose zod schema has been written and structs that
+	// refer to themselves
+	zodDeclared  map[*types.TypeName]bool
+	zodRecursive map[*types.TypeName]bool
 }
 
 func New() *Generator {
 	return &Generator{
-		parser:     NewParser(),
-		inputDir:   "",
-		outputFile: "",
+		parser:          NewParser(),
+		inputDir:        "",
+		outputFile:      "",
+		stringEnumStyle: StringEnumUnion,
+		embedStyle:      EmbedFlatten,
+		jsonSemantics:   true,
+		outputMode:      OutputTypeScript,
+		typeMappings:    make(typeMappings),
+		expanding:       make(map[*types.Named]bool),
 	}
 }
 
@@ -26,45 +87,427 @@ func (g *Generator) FromDir(dir string) *Generator {
 	return g
 }
 
+// sets a file system to read the go files below root from, e.g. an embed.FS
+func (g *Generator) FromFS(fsys fs.FS, root string) *Generator {
+	g.fsys = fsys
+	g.fsRoot = root
+	return g
+}
+
+// adds a go file given as source code, files in the same directory make up a
+// package and a "go.mod" source sets the module their imports are resolved in
+func (g *Generator) FromSource(filename, src string) *Generator {
+	if g.sources == nil {
+		g.sources = make(fstest.MapFS)
+	}
+	g.sources[path.Clean(filepath.ToSlash(filename))] = &fstest.MapFile{Data: []byte(src)}
+	return g
+}
+
+// sets the go package patterns to load, e.g. "./..." or "github.com/org/api/models"
+func (g *Generator) FromPackages(patterns ...string) *Generator {
+	g.patterns = append(g.patterns, patterns...)
+	return g
+}
+
 // sets the output file
 func (g *Generator) ToFile(file string) *Generator {
 	g.outputFile = file
 	return g
 }
 
-// generates the TypeScript code
-func (g *Generator) Generate() error {
-	if g.inputDir == "" {
-		return fmt.Errorf("input directory not set")
+// sets a writer the code is written to, like the output file
+func (g *Generator) ToWriter(w io.Writer) *Generator {
+	g.outputWriter = w
+	return g
+}
+
+// sets the output directory, one module is written per go package
+func (g *Generator) ToDir(dir string) *Generator {
+	g.outputDir = dir
+	return g
+}
+
+// sets the JSON Schema output file
+func (g *Generator) ToJSONSchema(file string) *Generator {
+	g.schemaFile = file
+	return g
+}
+
+// sets how string enums are written
+func (g *Generator) WithStringEnumStyle(style StringEnumStyle) *Generator {
+	g.stringEnumStyle = style
+	return g
+}
+
+// sets how embedded structs are written
+func (g *Generator) WithEmbedStyle(style EmbedStyle) *Generator {
+	g.embedStyle = style
+	return g
+}
+
+// sets the struct tag keys fields are named by, the first key a field is
+// tagged with is used, e.g. WithTagKey("bson", "json")
+func (g *Generator) WithTagKey(keys ...string) *Generator {
+	if len(keys) > 0 {
+		g.parser.tagKeys = keys
 	}
+	return g
+}
 
-	if g.outputFile == "" {
-		return fmt.Errorf("output file not set")
+// sets the build tags files are selected by, like go build -tags does. GOOS
+// and GOARCH are taken from the environment
+func (g *Generator) WithBuildTags(tags ...string) *Generator {
+	g.parser.loader.build.BuildTags = tags
+	return g
+}
+
+// sets whether fields are written as encoding/json serialises them, skipping
+// unexported and json:"-" fields and honouring the ",string" option
+func (g *Generator) WithJSONSemantics(enabled bool) *Generator {
+	g.jsonSemantics = enabled
+	return g
+}
+
+// maps a go type, given as "import/path.Name", "pkg.Name" or a basic type
+// name, to a TypeScript type
+func (g *Generator) WithTypeMapping(goType, tsType string) *Generator {
+	g.typeMappings[goType] = TSType{Type: tsType}
+	return g
+}
+
+// maps a go type to a TypeScript type that is imported from a module
+func (g *Generator) WithTypeMappingFrom(goType, tsType, module string) *Generator {
+	g.typeMappings[goType] = TSType{Type: tsType, From: module}
+	return g
+}
+
+// adds a mapper that is asked for every go type before the built-in mappings.
+// Types mapped with WithTypeMapping are not passed to it and mappers added
+// later are asked first
+func (g *Generator) WithTypeMapper(mapper TypeMapper) *Generator {
+	g.typeMappers = append(g.typeMappers, mapper)
+	return g
+}
+
+// adds a mapper that is asked for every struct field, with its struct tags,
+// before its type is mapped. Fields with a //gotots:type directive are not
+// passed to it and mappers added later are asked first
+func (g *Generator) WithFieldMapper(mapper FieldMapper) *Generator {
+	g.fieldMappers = append(g.fieldMappers, mapper)
+	return g
+}
+
+// sets how structs and enums of different packages that share a name are told apart
+func (g *Generator) WithCollisionStrategy(strategy CollisionStrategy) *Generator {
+	g.collisionStrategy = strategy
+	return g
+}
+
+// sets what is generated for the structs and enums
+func (g *Generator) WithOutputMode(mode OutputMode) *Generator {
+	g.outputMode = mode
+	return g
+}
+
+// only generates the structs and enums matching one of the patterns, a
+// regular expression of the type name, "pkg:<glob>" of the import path or
+// "file:<glob>" of the file, e.g. Include("Request$", "pkg:example.com/api/...")
+func (g *Generator) Include(patterns ...string) *Generator {
+	g.includes = append(g.includes, patterns...)
+	return g
+}
+
+// does not generate the structs and enums matching one of the patterns, see Include
+func (g *Generator) Exclude(patterns ...string) *Generator {
+	g.excludes = append(g.excludes, patterns...)
+	return g
+}
+
+// sets whether the structs and enums referred to by the included ones are
+// generated as well, instead of being written out in place
+func (g *Generator) WithIncludeReferenced(enabled bool) *Generator {
+	g.includeReferenced = enabled
+	return g
+}
+
+// only generates the given structs and the structs and enums they refer to,
+// through pointers, slices, maps, inline structs and other packages. Names may
+// be qualified, e.g. "api.CreateOrderRequest" or "example.com/app/api.CreateOrderRequest"
+func (g *Generator) Roots(names ...string) *Generator {
+	g.roots = append(g.roots, names...)
+	return g
+}
+
+// returns the types generated because they are reachable from the roots, and
+// the fields they were reached through, in the order they are generated
+func (g *Generator) References() []Reference {
+	return g.references
+}
+
+// generates the TypeScript code, it can be run again to pick up changes
+func (g *Generator) Generate() error {
+	if err := g.checkConfig(); err != nil {
+		return err
 	}
 
-	err := g.parser.FromDir(g.inputDir)
+	files, err := g.generateFiles()
 	if err != nil {
-		return fmt.Errorf("failed to parse directory: %w", err)
+		return err
 	}
 
-	ts := g.generateTypeScript()
-	err = os.WriteFile(g.outputFile, []byte(ts), 0644)
+	for _, file := range files {
+		if file.writer != nil {
+			if _, err := file.writer.Write(file.content); err != nil {
+				return fmt.Errorf("failed to write %s: %w", file.kind, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := writeFile(file.path, file.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.kind, err)
+		}
+	}
+	return nil
+}
+
+// generates the code in memory and compares it with the files written
+// before, it fails with a unified diff of the files that are out of date.
+// Code written to a writer is not compared, it fails when there is no file.
+func (g *Generator) Verify() error {
+	if err := g.checkConfig(); err != nil {
+		return err
+	}
+
+	files, err := g.generateFiles()
 	if err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+		return err
+	}
+
+	var diffs strings.Builder
+	verified := false
+	for _, file := range files {
+		if file.writer != nil {
+			continue
+		}
+		verified = true
+		existing, err := os.ReadFile(file.path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", file.kind, err)
+		}
+		if !bytes.Equal(existing, file.content) {
+			diffs.WriteString(unifiedDiff(file.path, string(existing), string(file.content)))
+		}
+	}
+	if !verified {
+		return fmt.Errorf("nothing to verify, the code is only written to a writer")
+	}
+	if diffs.Len() > 0 {
+		return fmt.Errorf("generated code is out of date:\n%s", strings.TrimSuffix(diffs.String(), "\n"))
+	}
+	return nil
+}
+
+// generates the code of the structs and enums in one file and returns it,
+// nothing is written
+func (g *Generator) GenerateString() (string, error) {
+	if err := g.checkInput(); err != nil {
+		return "", err
+	}
+	if err := g.parse(); err != nil {
+		return "", err
+	}
+	return g.generateOutput(g.parser.parseResult.Enums, g.parser.parseResult.Structs), nil
+}
+
+// a file of the generated code, kind names it in errors. The code is written
+// to writer instead of path when it is set
+type generatedFile struct {
+	path    string
+	content []byte
+	kind    string
+	writer  io.Writer
+}
+
+// parses the input and generates every configured output in memory
+func (g *Generator) generateFiles() ([]generatedFile, error) {
+	if err := g.parse(); err != nil {
+		return nil, err
+	}
+
+	var files []generatedFile
+	if g.outputFile != "" || g.outputWriter != nil {
+		ts := []byte(g.generateOutput(g.parser.parseResult.Enums, g.parser.parseResult.Structs))
+		if g.outputFile != "" {
+			files = append(files, generatedFile{path: g.outputFile, content: ts, kind: "output file"})
+		}
+		if g.outputWriter != nil {
+			files = append(files, generatedFile{content: ts, kind: "output", writer: g.outputWriter})
+		}
 	}
 
+	if g.outputDir != "" {
+		modules, err := g.generateModules()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, modules...)
+	}
+
+	if g.schemaFile != "" {
+		schema, err := g.generateJSONSchema()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JSON Schema: %w", err)
+		}
+		files = append(files, generatedFile{path: g.schemaFile, content: schema, kind: "JSON Schema file"})
+	}
+	return files, nil
+}
+
+// checks that an input and an output are set
+func (g *Generator) checkConfig() error {
+	if err := g.checkInput(); err != nil {
+		return err
+	}
+
+	if g.outputFile == "" && g.outputWriter == nil && g.outputDir == "" && g.schemaFile == "" {
+		return fmt.Errorf("output file not set")
+	}
+	return nil
+}
+
+// checks that an input is set
+func (g *Generator) checkInput() error {
+	if g.inputDir == "" && len(g.patterns) == 0 && g.fsys == nil && len(g.sources) == 0 {
+		return fmt.Errorf("input directory not set")
+	}
 	return nil
 }
 
+// parses the input, forgetting the results of an earlier run, and decides
+// which structs and enums are generated under which names
+func (g *Generator) parse() error {
+	g.parser.reset()
+
+	if g.inputDir != "" {
+		err := g.parser.FromDir(g.inputDir)
+		if err != nil {
+			return fmt.Errorf("failed to parse directory: %w", err)
+		}
+	}
+
+	if len(g.patterns) > 0 {
+		err := g.parser.FromPackages(g.patterns...)
+		if err != nil {
+			return fmt.Errorf("failed to load packages: %w", err)
+		}
+	}
+
+	if g.fsys != nil {
+		err := g.parser.FromFS(g.fsys, g.fsRoot)
+		if err != nil {
+			return fmt.Errorf("failed to parse file system: %w", err)
+		}
+	}
+
+	if len(g.sources) > 0 {
+		for filename := range g.sources {
+			if !fs.ValidPath(filename) {
+				return fmt.Errorf("invalid source file name %q", filename)
+			}
+		}
+		err := g.parser.FromFS(g.sources, ".")
+		if err != nil {
+			return fmt.Errorf("failed to parse sources: %w", err)
+		}
+	}
+
+	if err := g.filterTypes(); err != nil {
+		return fmt.Errorf("failed to filter types: %w", err)
+	}
+
+	if err := g.resolveCollisions(); err != nil {
+		return fmt.Errorf("failed to resolve type names: %w", err)
+	}
+	return nil
+}
+
+// writes a file unless it already has the content, so that tools watching
+// the output are not triggered by a run that changed nothing
+func writeFile(file string, content []byte) error {
+	if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	return os.WriteFile(file, content, 0644)
+}
+
+// generates the code for structs and enums in the configured output mode
+func (g *Generator) generateOutput(enums []EnumInfo, structs []StructInfo) string {
+	switch g.outputMode {
+	case OutputZod:
+		return g.generateZod(enums, structs)
+	default:
+		return g.generateTypeScript(enums, structs)
+	}
+}
+
 // generates the TypeScript code
-func (g *Generator) generateTypeScript() string {
+func (g *Generator) generateTypeScript(enums []EnumInfo, structs []StructInfo) string {
 	var sb strings.Builder
 
-	sb.WriteString("/* Do not change, this code is generated from Golang structs */\n\n")
+	g.imports = make(map[string]map[string]bool)
+
+	sb.WriteString(g.generateDeclarations(enums, structs, g.generateEnum, g.generateStruct))
 
-	for _, structInfo := range g.parser.parseResult.Structs {
-		sb.WriteString(g.generateStruct(structInfo))
-		sb.WriteString("\n")
+	header := "/* Do not change, this code is generated from Golang structs */\n\n"
+	if imports := g.generateImports(); imports != "" {
+		header += imports + "\n"
+	}
+
+	return header + sb.String()
+}
+
+// generates the declarations of enums and structs, those of namespaced
+// packages are wrapped in their namespace
+func (g *Generator) generateDeclarations(enums []EnumInfo, structs []StructInfo, generateEnum func(EnumInfo) string, generateStruct func(StructInfo) string) string {
+	var sb strings.Builder
+
+	var namespaces []string
+	bodies := make(map[string]*strings.Builder)
+	write := func(pkgPath, code string) {
+		namespace := ""
+		if g.module == nil {
+			namespace = g.namespaces[pkgPath]
+		}
+		if namespace == "" {
+			sb.WriteString(code + "\n")
+			return
+		}
+		if bodies[namespace] == nil {
+			namespaces = append(namespaces, namespace)
+			bodies[namespace] = &strings.Builder{}
+		}
+		bodies[namespace].WriteString(code + "\n")
+	}
+
+	for _, enumInfo := range enums {
+		write(enumInfo.PkgPath, generateEnum(enumInfo))
+	}
+	for _, structInfo := range structs {
+		write(structInfo.PkgPath, generateStruct(structInfo))
+	}
+
+	for _, namespace := range namespaces {
+		sb.WriteString("export namespace " + namespace + " {\n")
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(bodies[namespace].String(), "\n"), "\n") {
+			if strings.TrimSpace(line) != "" {
+				sb.WriteString("	")
+			}
+			sb.WriteString(line)
+		}
+		sb.WriteString("}\n\n")
 	}
 
 	return strings.TrimSuffix(sb.String(), "\n")
@@ -74,183 +517,422 @@ func (g *Generator) generateTypeScript() string {
 func (g *Generator) generateStruct(structInfo StructInfo) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("export interface %s {\n", structInfo.Name))
+	extends := ""
+	if g.embedStyle == EmbedExtends {
+		if _, embedded := g.extendedFields(structInfo); len(embedded) > 0 {
+			extends = " extends " + strings.Join(embedded, ", ")
+		}
+	}
+
+	sb.WriteString(tsDoc(structInfo.Doc, 0))
+
+	typeParams := ""
+	if structInfo.GoType != nil {
+		typeParams = g.typeParamsToTS(structInfo.GoType.TypeParams())
+		if tsType, ok := g.marshaledTypeToTS(structInfo.GoType); ok {
+			sb.WriteString(fmt.Sprintf("export type %s%s = %s;\n", structInfo.Name, typeParams, tsType))
+			return sb.String()
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("export interface %s%s%s {\n", structInfo.Name, typeParams, extends))
 	sb.WriteString(g.generateFields(structInfo, 1))
 	sb.WriteString("};\n")
 
 	return sb.String()
 }
 
+// generates the TypeScript code for an enum
+func (g *Generator) generateEnum(enumInfo EnumInfo) string {
+	if enumInfo.GoType != nil {
+		if tsType, ok := g.marshaledTypeToTS(enumInfo.GoType); ok {
+			return tsDoc(enumInfo.Doc, 0) + fmt.Sprintf("export type %s = %s;\n", enumInfo.Name, tsType)
+		}
+	}
+	if enumInfo.IsString {
+		return g.generateStringEnum(enumInfo)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(tsDoc(enumInfo.Doc, 0))
+	sb.WriteString(fmt.Sprintf("export enum %s {\n", enumInfo.Name))
+	for _, value := range enumInfo.Values {
+		sb.WriteString(tsDoc(value.Doc, 1))
+		sb.WriteString(fmt.Sprintf("	%s = %d,\n", value.Name, value.Value))
+	}
+	sb.WriteString("};\n")
+
+	return sb.String()
+}
+
+// generates the TypeScript code for a string enum in the configured style
+func (g *Generator) generateStringEnum(enumInfo EnumInfo) string {
+	var sb strings.Builder
+
+	sb.WriteString(tsDoc(enumInfo.Doc, 0))
+
+	switch g.stringEnumStyle {
+	case StringEnumEnum:
+		sb.WriteString(fmt.Sprintf("export enum %s {\n", enumInfo.Name))
+		for _, value := range enumInfo.Values {
+			sb.WriteString(tsDoc(value.Doc, 1))
+			sb.WriteString(fmt.Sprintf("	%s = %s,\n", value.Name, tsStringLiteral(value.StringValue)))
+		}
+		sb.WriteString("};\n")
+
+	case StringEnumConst:
+		sb.WriteString(fmt.Sprintf("export const %s = {\n", enumInfo.Name))
+		for _, value := range enumInfo.Values {
+			sb.WriteString(tsDoc(value.Doc, 1))
+			sb.WriteString(fmt.Sprintf("	%s: %s,\n", value.Name, tsStringLiteral(value.StringValue)))
+		}
+		sb.WriteString("} as const;\n")
+		sb.WriteString(fmt.Sprintf("export type %[1]s = typeof %[1]s[keyof typeof %[1]s];\n", enumInfo.Name))
+
+	default:
+		sb.WriteString(fmt.Sprintf("export type %s = %s;\n", enumInfo.Name, strings.Join(stringEnumLiterals(enumInfo), " | ")))
+	}
+
+	return sb.String()
+}
+
+// returns the TypeScript literals of the values of a string enum, once each,
+// aliased constants such as const Alias = Admin repeat a value
+func stringEnumLiterals(enumInfo EnumInfo) []string {
+	literals := make([]string, 0, len(enumInfo.Values))
+	seen := make(map[string]bool)
+	for _, value := range enumInfo.Values {
+		if !seen[value.StringValue] {
+			seen[value.StringValue] = true
+			literals = append(literals, tsStringLiteral(value.StringValue))
+		}
+	}
+	return literals
+}
+
 func (g *Generator) generateFields(structInfo StructInfo, indentLevel int) string {
 	var sb strings.Builder
 
-	for _, field := range structInfo.Fields {
+	for _, field := range g.structFields(structInfo) {
 		tsType := g.goTypeToTS(field, indentLevel)
 		optionalMarker := ""
 		if field.IsOptional {
 			optionalMarker = "?"
 		}
+		readonly := ""
+		if field.IsReadonly {
+			readonly = "readonly "
+		}
 
 		fieldName := field.Name
 		if field.JSONTag != "" {
 			fieldName = field.JSONTag
 		}
 
-		sb.WriteString(fmt.Sprintf("%s%s%s: %s;\n", strings.Repeat("	", indentLevel), fieldName, optionalMarker, tsType))
+		sb.WriteString(tsDoc(field.Doc, indentLevel))
+		sb.WriteString(fmt.Sprintf("%s%s%s%s: %s;\n", strings.Repeat("	", indentLevel), readonly, tsPropertyName(fieldName), optionalMarker, tsType))
 	}
 
 	return sb.String()
 }
 
-// converts a go type to a TypeScript type
+// converts the go type of a field to a TypeScript type
 func (g *Generator) goTypeToTS(field FieldInfo, indentLevel int) string {
-	goType := field.Type
-
+	if field.TSType != "" {
+		return field.TSType
+	}
 	if field.EmbeddedStruct != nil {
-		return fmt.Sprintf("{\n%s%s}", g.generateFields(*field.EmbeddedStruct, indentLevel+1), strings.Repeat("	", indentLevel))
+		return g.inlineStructToTS(*field.EmbeddedStruct, indentLevel)
+	}
+	if tsType, ok := g.mapField(field); ok {
+		return g.useTSType(tsType)
 	}
 
-	isArray := strings.HasPrefix(goType, "[]")
-	if isArray {
-		goType = strings.TrimPrefix(goType, "[]")
+	if g.isQuotedField(field) {
+		if field.IsPointer {
+			return "string | null"
+		}
+		return "string"
 	}
 
-	isPointer := strings.HasPrefix(goType, "*")
-	if isPointer {
-		goType = strings.TrimPrefix(goType, "*")
+	if tsType, ok := g.oneOfToTS(field); ok {
+		return tsType
 	}
 
-	if strings.HasPrefix(goType, "map[") {
-		return g.mapTypeToTS(goType)
+	return g.typeToTS(field.GoType, indentLevel)
+}
+
+// converts a go type to a TypeScript type
+func (g *Generator) typeToTS(goType types.Type, indentLevel int) string {
+	return walkType(g, goType, func(c typeClass) string {
+		switch c.kind {
+		case kindFixed:
+			return c.tsType
+		case kindBasic:
+			return basicTypeToTS(c.basic)
+		case kindPointer:
+			return nullableTypeToTS(g.typeToTS(c.elem, indentLevel))
+		case kindBytes:
+			return "string"
+		case kindSlice, kindArray:
+			return arrayTypeToTS(g.typeToTS(c.elem, indentLevel))
+		case kindMap:
+			return fmt.Sprintf("Record<%s, %s>", mapKeyTypeToTS(c.key), g.typeToTS(c.elem, indentLevel))
+		case kindStruct:
+			return g.inlineStructToTS(*c.structInfo, indentLevel)
+		case kindStructRef:
+			return g.declarationName(c.structInfo.PkgPath, c.structInfo.Name, false) + g.typeArgsToTS(c.named.TypeArgs())
+		case kindEnumRef:
+			return g.declarationName(c.enumInfo.PkgPath, c.enumInfo.Name, false)
+		case kindInterface, kindRecursive:
+			return "any"
+		case kindTypeParam:
+			return c.param.Obj().Name()
+		default:
+			return "unknown"
+		}
+	})
+}
+
+// returns the TypeScript type of a type that encoding/json does not write
+// from its layout, set with a //gotots:type directive or "unknown" for a
+// json.Marshaler and "string" for an encoding.TextMarshaler
+func (g *Generator) marshaledTypeToTS(named *types.Named) (string, bool) {
+	if tsType := g.parser.typeDirectives[named.Origin().Obj()]["type"]; tsType != "" {
+		return tsType, true
+	}
+	switch g.parser.marshalerOf(named) {
+	case jsonMarshaler:
+		return "unknown", true
+	case textMarshaler:
+		return "string", true
 	}
+	return "", false
+}
 
-	var tsType string
+// returns the TypeScript type parameters of a generic struct, e.g. "<T, N extends number = number>"
+func (g *Generator) typeParamsToTS(params *types.TypeParamList) string {
+	if params.Len() == 0 {
+		return ""
+	}
 
-	isKnownStruct := false
-	for _, s := range g.parser.parseResult.Structs {
-		if s.Name == goType {
-			isKnownStruct = true
-			break
+	// a parameter with a default can not come before one without, so only
+	// the constrained parameters after the last unconstrained one get one
+	constraints := make([]string, params.Len())
+	firstDefault := 0
+	for i := range constraints {
+		constraints[i] = g.constraintToTS(params.At(i).Constraint())
+		if constraints[i] == "" {
+			firstDefault = i + 1
 		}
 	}
 
-	if isKnownStruct {
-		tsType = goType
-	} else {
-		tsType = g.basicTypeToTS(goType)
+	tsParams := make([]string, 0, params.Len())
+	for i, constraint := range constraints {
+		tsParam := params.At(i).Obj().Name()
+		if constraint != "" {
+			tsParam += " extends " + constraint
+			if i >= firstDefault {
+				tsParam += " = " + constraint
+			}
+		}
+		tsParams = append(tsParams, tsParam)
+	}
+	return "<" + strings.Join(tsParams, ", ") + ">"
+}
+
+// returns the TypeScript type arguments of an instantiated generic type, e.g. "<User>"
+func (g *Generator) typeArgsToTS(args *types.TypeList) string {
+	if args.Len() == 0 {
+		return ""
+	}
+
+	tsArgs := make([]string, 0, args.Len())
+	for i := 0; i < args.Len(); i++ {
+		tsArgs = append(tsArgs, g.typeToTS(args.At(i), 0))
+	}
+	return "<" + strings.Join(tsArgs, ", ") + ">"
+}
+
+// converts the type terms of a constraint to a TypeScript union, or "" when
+// the constraint does not limit the type set, e.g. any or comparable
+func (g *Generator) constraintToTS(constraint types.Type) string {
+	iface, ok := constraint.Underlying().(*types.Interface)
+	if !ok {
+		return ""
 	}
 
-	if isArray {
-		tsType = tsType + "[]"
+	var terms []string
+	seen := make(map[string]bool)
+	add := func(tsType string) {
+		if tsType != "" && !seen[tsType] {
+			seen[tsType] = true
+			terms = append(terms, tsType)
+		}
 	}
 
-	if isPointer {
-		tsType = tsType + " | null"
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		switch embedded := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < embedded.Len(); j++ {
+				add(g.typeToTS(embedded.Term(j).Type(), 0))
+			}
+		default:
+			if _, ok := embedded.Underlying().(*types.Interface); ok {
+				add(g.constraintToTS(embedded))
+			} else {
+				add(g.typeToTS(embedded, 0))
+			}
+		}
 	}
+	return strings.Join(terms, " | ")
+}
 
-	return tsType
+// writes the fields of a struct as an inline object type
+func (g *Generator) inlineStructToTS(structInfo StructInfo, indentLevel int) string {
+	return fmt.Sprintf("{\n%s%s}", g.generateFields(structInfo, indentLevel+1), strings.Repeat("	", indentLevel))
 }
 
 // converts a basic go type to a TypeScript type
-func (g *Generator) basicTypeToTS(goType string) string {
-	switch goType {
-	case "string":
+func basicTypeToTS(basic *types.Basic) string {
+	info := basic.Info()
+	switch {
+	case info&types.IsString != 0:
 		return "string"
-
-	case "int", "int8", "int16", "int32", "int64",
-		"uint", "uint8", "uint16", "uint32", "uint64",
-		"float32", "float64", "byte", "rune":
+	case info&types.IsBoolean != 0:
+		return "boolean"
+	case info&(types.IsInteger|types.IsFloat) != 0:
 		return "number"
+	case basic.Kind() == types.UntypedNil:
+		return "null"
+	default:
+		// complex numbers and unsafe pointers have no json representation
+		return "unknown"
+	}
+}
 
-	case "bool":
-		return "boolean"
+// converts a well known named type, given as "package.Name", to a TypeScript type
+func knownTypeToTS(name string) (string, bool) {
+	switch name {
+	case "time.Time":
+		return "string", true
 
-	case "interface{}", "any":
-		return "any"
+	case "time.Duration":
+		return "number", true
 
-	case "time.Time", "Time":
-		return "string"
+	case "uuid.UUID": // github.com/google/uuid, github.com/gofrs/uuid, github.com/satori/go.uuid
+		return "string", true
 
-	case "time.Duration", "Duration":
-		return "number"
+	case "json.RawMessage", "jsontext.Value":
+		return "any", true
 
-	case "uuid.UUID", "UUID",
-		"google.uuid.UUID", // github.com/google/uuid
-		"gofrs.uuid.UUID",  // github.com/gofrs/uuid
-		"satori.uuid.UUID": // github.com/satori/go.uuid
-		return "string"
+	case "sql.NullString":
+		return "string | null", true
+	case "sql.NullInt64", "sql.NullInt32", "sql.NullInt16", "sql.NullByte":
+		return "number | null", true
+	case "sql.NullFloat64":
+		return "number | null", true
+	case "sql.NullBool":
+		return "boolean | null", true
+	case "sql.NullTime":
+		return "string | null", true
 
-	case "json.RawMessage", "RawMessage":
-		return "any"
-
-	case "sql.NullString", "NullString":
-		return "string | null"
-	case "sql.NullInt64", "NullInt64", "sql.NullInt32", "NullInt32", "sql.NullInt16", "NullInt16":
-		return "number | null"
-	case "sql.NullFloat64", "NullFloat64":
-		return "number | null"
-	case "sql.NullBool", "NullBool":
-		return "boolean | null"
-	case "sql.NullTime", "NullTime":
-		return "string | null"
-
-	case "decimal.Decimal", "Decimal":
-		return "string"
+	case "decimal.Decimal":
+		return "string", true
 
 	case "big.Int", "big.Float", "big.Rat":
-		return "string"
+		return "string", true
 
-	case "net.IP", "IP":
-		return "string"
-	case "net.URL", "url.URL", "URL":
-		return "string"
-
-	case "[]byte":
-		return "string"
+	case "net.IP":
+		return "string", true
+	case "url.URL":
+		return "string", true
 
 	default:
-		if idx := strings.LastIndex(goType, "."); idx != -1 {
-			return goType[idx+1:]
-		}
-		return goType
+		return "", false
 	}
 }
 
-// converts a map go type to a TypeScript type
-func (g *Generator) mapTypeToTS(goType string) string {
-	if !strings.HasPrefix(goType, "map[") {
-		return "Record<string, any>"
+// converts the key type of a go map to a TypeScript type
+func mapKeyTypeToTS(goType types.Type) string {
+	if basic, ok := goType.Underlying().(*types.Basic); ok && basic.Info()&types.IsInteger != 0 {
+		return "number"
 	}
+	return "string"
+}
 
+// returns the TypeScript array type of an element type
+func arrayTypeToTS(tsType string) string {
+	if isUnionType(tsType) {
+		return "(" + tsType + ")[]"
+	}
+	return tsType + "[]"
+}
+
+// returns the nullable TypeScript type of a type
+func nullableTypeToTS(tsType string) string {
+	if tsType == "null" || strings.HasSuffix(tsType, " | null") {
+		return tsType
+	}
+	return tsType + " | null"
+}
+
+// reports whether a TypeScript type is a union at its top level
+func isUnionType(tsType string) bool {
 	depth := 0
-	keyEnd := -1
-	for i := 4; i < len(goType); i++ {
-		if goType[i] == '[' {
+	for _, r := range tsType {
+		switch r {
+		case '<', '{', '(', '[':
 			depth++
-		} else if goType[i] == ']' {
+		case '>', '}', ')', ']':
+			depth--
+		case '|':
 			if depth == 0 {
-				keyEnd = i
-				break
+				return true
 			}
-			depth--
 		}
 	}
+	return false
+}
 
-	if keyEnd == -1 {
-		return "Record<string, any>"
+// reports whether the ",string" json option applies to a go type
+func isQuotableType(goType types.Type) bool {
+	goType = types.Unalias(goType)
+	if pointer, ok := goType.(*types.Pointer); ok {
+		goType = pointer.Elem()
 	}
+	basic, ok := goType.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat) != 0
+}
 
-	keyType := goType[4:keyEnd]
-	valueType := goType[keyEnd+1:]
+// reports whether a go type is a byte
+func isByteType(goType types.Type) bool {
+	basic, ok := goType.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
 
-	tsKeyType := "string"
-	switch keyType {
-	case "int", "int8", "int16", "int32", "int64",
-		"uint", "uint8", "uint16", "uint32", "uint64":
-		tsKeyType = "number"
+// returns a property name, quoted when it is not a valid identifier
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return tsStringLiteral(name)
 	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
 
-	tsValueType := g.basicTypeToTS(valueType)
-
-	return fmt.Sprintf("Record<%s, %s>", tsKeyType, tsValueType)
+// quotes a string as a TypeScript string literal
+func tsStringLiteral(value string) string {
+	var sb strings.Builder
+	encoder := json.NewEncoder(&sb)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return `""`
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
 }
@@ -0,0 +1,330 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/types"
+	"strings"
+)
+
+// URI of the JSON Schema dialect that is generated
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// a JSON Schema, only the keywords gotots writes
+type jsonSchema struct {
+	Schema               string            `json:"$schema,omitempty"`
+	Ref                  string            `json:"$ref,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Deprecated           bool              `json:"deprecated,omitempty"`
+	ReadOnly             bool              `json:"readOnly,omitempty"`
+	Type                 any               `json:"type,omitempty"` // a type name or a list of them
+	Format               string            `json:"format,omitempty"`
+	MinLength            *int              `json:"minLength,omitempty"`
+	MaxLength            *int              `json:"maxLength,omitempty"`
+	Minimum              json.Number       `json:"minimum,omitempty"`
+	Maximum              json.Number       `json:"maximum,omitempty"`
+	ContentEncoding      string            `json:"contentEncoding,omitempty"`
+	Enum                 []any             `json:"enum,omitempty"`
+	Items                *jsonSchema       `json:"items,omitempty"`
+	MinItems             *int              `json:"minItems,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"`
+	Properties           *jsonSchemaFields `json:"properties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	AdditionalProperties *jsonSchema       `json:"additionalProperties,omitempty"`
+	AnyOf                []*jsonSchema     `json:"anyOf,omitempty"`
+	Defs                 *jsonSchemaFields `json:"$defs,omitempty"`
+}
+
+// named schemas that keep their order when encoded
+type jsonSchemaFields struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+func (f *jsonSchemaFields) set(name string, schema *jsonSchema) {
+	if f.schemas == nil {
+		f.schemas = make(map[string]*jsonSchema)
+	}
+	if _, ok := f.schemas[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.schemas[name] = schema
+}
+
+func (f *jsonSchemaFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range f.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// generates a JSON Schema document with the structs and enums in $defs
+func (g *Generator) generateJSONSchema() ([]byte, error) {
+	defs := &jsonSchemaFields{}
+
+	// mapped types can not be imported into a schema
+	g.imports = make(map[string]map[string]bool)
+
+	for _, enumInfo := range g.parser.parseResult.Enums {
+		defs.set(g.defName(enumInfo.PkgPath, enumInfo.Name), g.enumSchema(enumInfo))
+	}
+	for _, structInfo := range g.parser.parseResult.Structs {
+		schema := g.structSchema(structInfo)
+		if structInfo.GoType != nil {
+			if marshaled, ok := g.marshaledSchema(structInfo.GoType); ok {
+				schema = marshaled
+			}
+		}
+		setDoc(schema, structInfo.Doc)
+		defs.set(g.defName(structInfo.PkgPath, structInfo.Name), schema)
+	}
+
+	document := &jsonSchema{
+		Schema: jsonSchemaDialect,
+		Defs:   defs,
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// returns the name of a declaration in $defs, qualified with the namespace
+// of its package if it has one
+func (g *Generator) defName(pkgPath, name string) string {
+	if namespace := g.namespaces[pkgPath]; namespace != "" {
+		return namespace + "." + name
+	}
+	return name
+}
+
+// returns the schema of an enum, listing its values
+func (g *Generator) enumSchema(enumInfo EnumInfo) *jsonSchema {
+	if marshaled, ok := g.marshaledSchema(enumInfo.GoType); ok {
+		setDoc(marshaled, enumInfo.Doc)
+		return marshaled
+	}
+
+	schema := &jsonSchema{Type: "integer"}
+	if enumInfo.IsString {
+		schema.Type = "string"
+	}
+	// aliased constants repeat a value, the values of an enum are unique
+	seen := make(map[any]bool)
+	for _, value := range enumInfo.Values {
+		var enumValue any = value.Value
+		if enumInfo.IsString {
+			enumValue = value.StringValue
+		}
+		if !seen[enumValue] {
+			seen[enumValue] = true
+			schema.Enum = append(schema.Enum, enumValue)
+		}
+	}
+	setDoc(schema, enumInfo.Doc)
+	return schema
+}
+
+// returns the object schema of a struct, fields that are not optional are required
+func (g *Generator) structSchema(structInfo StructInfo) *jsonSchema {
+	schema := &jsonSchema{
+		Type:       "object",
+		Properties: &jsonSchemaFields{},
+	}
+	for _, field := range g.promotedFields(structInfo) {
+		fieldName := field.Name
+		if field.JSONTag != "" {
+			fieldName = field.JSONTag
+		}
+
+		fieldSchema := g.fieldSchema(field)
+		setDoc(fieldSchema, field.Doc)
+		fieldSchema.ReadOnly = field.IsReadonly
+		schema.Properties.set(fieldName, fieldSchema)
+		if !field.IsOptional {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+	return schema
+}
+
+// converts the go type of a field to a schema, like goTypeToTS
+func (g *Generator) fieldSchema(field FieldInfo) *jsonSchema {
+	if field.TSType != "" {
+		return schemaFromTS(field.TSType)
+	}
+	if field.EmbeddedStruct != nil {
+		return g.structSchema(*field.EmbeddedStruct)
+	}
+	if tsType, ok := g.mapField(field); ok {
+		return schemaFromTS(tsType.Type)
+	}
+
+	if g.isQuotedField(field) {
+		if field.IsPointer {
+			return &jsonSchema{Type: []string{"string", "null"}}
+		}
+		return &jsonSchema{Type: "string"}
+	}
+
+	if schema, ok := g.validatedSchema(field); ok {
+		return schema
+	}
+
+	return g.typeSchema(field.GoType)
+}
+
+// converts a go type to a schema, like typeToTS
+func (g *Generator) typeSchema(goType types.Type) *jsonSchema {
+	return walkType(g, goType, func(c typeClass) *jsonSchema {
+		switch c.kind {
+		case kindFixed:
+			if schema, ok := knownTypeSchema(c.known); ok {
+				return schema
+			}
+			return schemaFromTS(c.tsType)
+		case kindBasic:
+			info := c.basic.Info()
+			switch {
+			case info&types.IsInteger != 0:
+				return &jsonSchema{Type: "integer"}
+			case info&types.IsFloat != 0:
+				return &jsonSchema{Type: "number"}
+			}
+			return schemaFromTS(basicTypeToTS(c.basic))
+		case kindPointer:
+			return nullableSchema(g.typeSchema(c.elem))
+		case kindBytes:
+			return &jsonSchema{Type: "string", ContentEncoding: "base64"}
+		case kindSlice:
+			return &jsonSchema{Type: "array", Items: g.typeSchema(c.elem)}
+		case kindArray:
+			length := int(c.length)
+			return &jsonSchema{Type: "array", Items: g.typeSchema(c.elem), MinItems: &length, MaxItems: &length}
+		case kindMap:
+			return &jsonSchema{Type: "object", AdditionalProperties: g.typeSchema(c.elem)}
+		case kindStruct:
+			return g.structSchema(*c.structInfo)
+		case kindStructRef:
+			return g.structRefSchema(c.named, c.structInfo)
+		case kindEnumRef:
+			return &jsonSchema{Ref: "#/$defs/" + g.defName(c.enumInfo.PkgPath, c.enumInfo.Name)}
+		default:
+			// interfaces, type parameters and types without a json
+			// representation accept anything
+			return &jsonSchema{}
+		}
+	})
+}
+
+// returns the schema of a parsed struct, a reference to its definition or
+// for generic structs the struct written out with its type arguments
+func (g *Generator) structRefSchema(named *types.Named, structInfo *StructInfo) *jsonSchema {
+	structType, ok := named.Underlying().(*types.Struct)
+	if !ok || named.TypeArgs().Len() == 0 {
+		return &jsonSchema{Ref: "#/$defs/" + g.defName(structInfo.PkgPath, structInfo.Name)}
+	}
+	if g.expanding[named] {
+		return &jsonSchema{}
+	}
+	g.expanding[named] = true
+	defer delete(g.expanding, named)
+	obj := named.Obj()
+	return g.structSchema(*g.parser.typeStruct(structType, obj.Name(), obj.Pkg()))
+}
+
+// returns the schema of a type that encoding/json does not write from its
+// layout, like marshaledTypeToTS
+func (g *Generator) marshaledSchema(named *types.Named) (*jsonSchema, bool) {
+	tsType, ok := g.marshaledTypeToTS(named)
+	if !ok {
+		return nil, false
+	}
+	return schemaFromTS(tsType), true
+}
+
+// returns the schema of the types knownTypeToTS handles, with a format
+// where JSON Schema has one
+func knownTypeSchema(name string) (*jsonSchema, bool) {
+	switch name {
+	case "time.Time":
+		return &jsonSchema{Type: "string", Format: "date-time"}, true
+	case "sql.NullTime":
+		return &jsonSchema{Type: []string{"string", "null"}, Format: "date-time"}, true
+	case "time.Duration":
+		return &jsonSchema{Type: "integer"}, true
+	case "uuid.UUID":
+		return &jsonSchema{Type: "string", Format: "uuid"}, true
+	case "url.URL":
+		return &jsonSchema{Type: "string", Format: "uri"}, true
+	case "sql.NullInt64", "sql.NullInt32", "sql.NullInt16", "sql.NullByte":
+		return &jsonSchema{Type: []string{"integer", "null"}}, true
+	}
+
+	tsType, ok := knownTypeToTS(name)
+	if !ok {
+		return nil, false
+	}
+	return schemaFromTS(tsType), true
+}
+
+// converts a TypeScript type to a schema, types without an equivalent
+// accept anything
+func schemaFromTS(tsType string) *jsonSchema {
+	switch tsType {
+	case "string", "number", "boolean", "null":
+		return &jsonSchema{Type: tsType}
+	}
+	if inner, ok := strings.CutSuffix(tsType, " | null"); ok && !isUnionType(inner) {
+		return nullableSchema(schemaFromTS(inner))
+	}
+	return &jsonSchema{}
+}
+
+// allows null in addition to a schema
+func nullableSchema(schema *jsonSchema) *jsonSchema {
+	switch t := schema.Type.(type) {
+	case string:
+		schema.Type = []string{t, "null"}
+		return schema
+	case []string:
+		schema.Type = append(t, "null")
+		return schema
+	}
+	if schema.Ref == "" && schema.AnyOf == nil && schema.Enum == nil {
+		// the schema accepts anything already
+		return schema
+	}
+	return &jsonSchema{AnyOf: []*jsonSchema{schema, {Type: "null"}}}
+}
+
+// sets the description of a schema from a doc comment, "Deprecated:"
+// paragraphs mark it deprecated
+func setDoc(schema *jsonSchema, doc string) {
+	if doc == "" {
+		return
+	}
+	schema.Description = doc
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			schema.Deprecated = true
+		}
+	}
+}
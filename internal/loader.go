@@ -0,0 +1,490 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+)
+
+// importer for the standard library, shared because loading export data is slow
+var stdImporter = struct {
+	sync.Mutex
+	importer types.Importer
+}{
+	importer: importer.Default(),
+}
+
+// a type-checked go package
+type loadedPackage struct {
+	dir   string
+	path  string
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// loads and type-checks go packages from source. Imports are resolved from
+// the standard library, from the directories of the enclosing module and, as
+// a last resort, with stub packages that only know the names used from them.
+type loader struct {
+	fset *token.FileSet
+
+	// file system the files are read from, the os one when nil. Paths in it
+	// are relative to its root.
+	fsys fs.FS
+
+	// build tags, GOOS and GOARCH files are selected by
+	build build.Context
+
+	// module enclosing the loaded directories
+	modulePath string
+	moduleDir  string
+
+	// import path of every directory that has been scanned
+	dirPaths map[string]string
+
+	// packages of every loaded directory
+	packages map[string][]*loadedPackage
+	loading  map[string]bool
+	stubs    map[string]*types.Package
+
+	// parsed files, kept across loads so that only changed files are parsed again
+	files map[string]parsedFile
+}
+
+// a parsed file and the hash of the source it was parsed from
+type parsedFile struct {
+	file *ast.File
+	sum  [sha256.Size]byte
+}
+
+func newLoader(fset *token.FileSet) *loader {
+	return &loader{
+		fset:     fset,
+		build:    build.Default,
+		dirPaths: make(map[string]string),
+		packages: make(map[string][]*loadedPackage),
+		loading:  make(map[string]bool),
+		stubs:    make(map[string]*types.Package),
+		files:    make(map[string]parsedFile),
+	}
+}
+
+// forgets the loaded packages so that they are loaded again, the parsed
+// files of unchanged files are reused
+func (l *loader) reset() {
+	l.modulePath = ""
+	l.moduleDir = ""
+	l.dirPaths = make(map[string]string)
+	l.packages = make(map[string][]*loadedPackage)
+	l.loading = make(map[string]bool)
+	l.stubs = make(map[string]*types.Package)
+}
+
+// parses a file, or returns the file parsed before if its content did not
+// change since. Modification times are not compared, an edit that keeps the
+// size within their granularity would go unnoticed. Files of other file
+// systems are always parsed, their paths may name different files.
+func (l *loader) parseFile(filename string) (*ast.File, error) {
+	src, err := l.readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if l.fsys != nil {
+		return parser.ParseFile(l.fset, filename, src, parser.ParseComments)
+	}
+
+	sum := sha256.Sum256(src)
+	if cached, ok := l.files[filename]; ok && cached.sum == sum {
+		return cached.file, nil
+	}
+
+	file, err := parser.ParseFile(l.fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	l.files[filename] = parsedFile{file: file, sum: sum}
+	return file, nil
+}
+
+func (l *loader) readFile(name string) ([]byte, error) {
+	if l.fsys != nil {
+		return fs.ReadFile(l.fsys, filepath.ToSlash(name))
+	}
+	return os.ReadFile(name)
+}
+
+func (l *loader) readDir(name string) ([]fs.DirEntry, error) {
+	if l.fsys != nil {
+		return fs.ReadDir(l.fsys, filepath.ToSlash(name))
+	}
+	return os.ReadDir(name)
+}
+
+func (l *loader) stat(name string) (fs.FileInfo, error) {
+	if l.fsys != nil {
+		return fs.Stat(l.fsys, filepath.ToSlash(name))
+	}
+	return os.Stat(name)
+}
+
+// returns the absolute path of a file, paths of other file systems are
+// relative to their root and only cleaned
+func (l *loader) abs(name string) (string, error) {
+	if l.fsys != nil {
+		return filepath.Clean(name), nil
+	}
+	return filepath.Abs(name)
+}
+
+// walks the directories below root, skipping those the go tool skips
+func (l *loader) walkDirs(root string, fn func(dir string)) error {
+	walk := func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		path = filepath.FromSlash(path)
+		if path != root && isIgnoredDir(entry.Name()) {
+			return filepath.SkipDir
+		}
+		fn(path)
+		return nil
+	}
+	if l.fsys != nil {
+		return fs.WalkDir(l.fsys, filepath.ToSlash(root), walk)
+	}
+	return filepath.WalkDir(root, walk)
+}
+
+// finds the module enclosing a directory
+func (l *loader) findModule(dir string) {
+	dir, err := l.abs(dir)
+	if err != nil {
+		return
+	}
+	for {
+		data, err := l.readFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			l.modulePath = modulePath(data)
+			l.moduleDir = dir
+			return
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+}
+
+// registers a scanned directory, root is the directory the scan started from
+func (l *loader) addDir(root, dir string) string {
+	absDir, err := l.abs(dir)
+	if err != nil {
+		absDir = dir
+	}
+
+	importPath := ""
+	if l.moduleDir != "" {
+		if rel, err := filepath.Rel(l.moduleDir, absDir); err == nil && !strings.HasPrefix(rel, "..") {
+			importPath = path.Join(l.modulePath, filepath.ToSlash(rel))
+		}
+	}
+	if importPath == "" {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			rel = dir
+		}
+		importPath = filepath.ToSlash(rel)
+	}
+
+	l.dirPaths[absDir] = importPath
+	return importPath
+}
+
+// loads the packages in a directory
+func (l *loader) loadDir(dir string) ([]*loadedPackage, error) {
+	absDir, err := l.abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if packages, ok := l.packages[absDir]; ok {
+		return packages, nil
+	}
+	importPath, ok := l.dirPaths[absDir]
+	if !ok {
+		importPath = filepath.ToSlash(dir)
+	}
+
+	entries, err := l.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !l.isSourceFile(dir, entry.Name()) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	// a directory may hold files of several packages, e.g. a main package
+	// excluded by a build tag, they are checked separately
+	var pkgNames []string
+	byName := make(map[string][]*ast.File)
+	for _, name := range names {
+		file, err := l.parseFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byName[file.Name.Name]; !ok {
+			pkgNames = append(pkgNames, file.Name.Name)
+		}
+		byName[file.Name.Name] = append(byName[file.Name.Name], file)
+	}
+
+	l.loading[importPath] = true
+	defer delete(l.loading, importPath)
+
+	var packages []*loadedPackage
+	for _, pkgName := range pkgNames {
+		packages = append(packages, l.check(dir, importPath, byName[pkgName]))
+	}
+	l.packages[absDir] = packages
+
+	return packages, nil
+}
+
+// type-checks the files of a package, errors are ignored so that a package
+// with unresolved imports still yields the types that could be resolved
+func (l *loader) check(dir, importPath string, files []*ast.File) *loadedPackage {
+	l.populateStubs(files)
+
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	config := types.Config{
+		Importer: l,
+		Error:    func(error) {},
+	}
+	typesPkg, _ := config.Check(importPath, l.fset, files, info)
+
+	return &loadedPackage{
+		dir:   dir,
+		path:  importPath,
+		files: files,
+		types: typesPkg,
+		info:  info,
+	}
+}
+
+// implements types.Importer
+func (l *loader) Import(importPath string) (*types.Package, error) {
+	if importPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	if dir, ok := l.localDir(importPath); ok {
+		if l.loading[importPath] {
+			return nil, fmt.Errorf("import cycle through %s", importPath)
+		}
+		packages, err := l.loadDir(dir)
+		if err == nil && len(packages) > 0 && packages[0].types != nil {
+			return packages[0].types, nil
+		}
+	}
+
+	if isStdPath(importPath) {
+		stdImporter.Lock()
+		pkg, err := stdImporter.importer.Import(importPath)
+		stdImporter.Unlock()
+		if err == nil {
+			return pkg, nil
+		}
+	}
+
+	return l.stub(importPath), nil
+}
+
+// returns the directory of a package that is part of the module or was scanned
+func (l *loader) localDir(importPath string) (string, bool) {
+	for dir, dirPath := range l.dirPaths {
+		if dirPath == importPath {
+			return dir, true
+		}
+	}
+
+	if l.modulePath != "" && (importPath == l.modulePath || strings.HasPrefix(importPath, l.modulePath+"/")) {
+		dir := filepath.Join(l.moduleDir, filepath.FromSlash(strings.TrimPrefix(importPath, l.modulePath)))
+		if info, err := l.stat(dir); err == nil && info.IsDir() {
+			l.dirPaths[dir] = importPath
+			return dir, true
+		}
+		return "", false
+	}
+
+	// outside of a module, match the import path against the scanned directories
+	best := ""
+	for dir, dirPath := range l.dirPaths {
+		if dirPath != "." && strings.HasSuffix(importPath, "/"+dirPath) && len(dir) > len(best) {
+			best = dir
+		}
+	}
+	return best, best != ""
+}
+
+// returns the stub package of an import path that could not be loaded
+func (l *loader) stub(importPath string) *types.Package {
+	pkg, ok := l.stubs[importPath]
+	if !ok {
+		pkg = types.NewPackage(importPath, guessPackageName(importPath))
+		pkg.MarkComplete()
+		l.stubs[importPath] = pkg
+	}
+	return pkg
+}
+
+// reports whether a package is a stub of an import that could not be loaded
+func (l *loader) isStub(pkg *types.Package) bool {
+	return pkg != nil && l.stubs[pkg.Path()] == pkg
+}
+
+// declares the names the files use from packages that will be stubbed, so
+// that a type such as uuid.UUID is known by name even if its package is not
+func (l *loader) populateStubs(files []*ast.File) {
+	for _, file := range files {
+		imports := make(map[string]string)
+		for _, spec := range file.Imports {
+			importPath := strings.Trim(spec.Path.Value, `"`)
+			if _, ok := l.localDir(importPath); ok || isStdPath(importPath) {
+				continue
+			}
+			name := guessPackageName(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = importPath
+		}
+		if len(imports) == 0 {
+			continue
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			ident, ok := selector.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			importPath, ok := imports[ident.Name]
+			if !ok {
+				return true
+			}
+
+			pkg := l.stub(importPath)
+			if pkg.Scope().Lookup(selector.Sel.Name) == nil {
+				obj := types.NewTypeName(token.NoPos, pkg, selector.Sel.Name, nil)
+				types.NewNamed(obj, types.NewInterfaceType(nil, nil), nil)
+				pkg.Scope().Insert(obj)
+			}
+			return true
+		})
+	}
+}
+
+// reports whether an import path belongs to the standard library
+func isStdPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// reports whether a file is a non-test go source file the go tool would build
+// with the build tags, GOOS and GOARCH of the loader
+func (l *loader) isSourceFile(dir, name string) bool {
+	if strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	ctxt := l.build
+	if l.fsys != nil {
+		ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+			return l.fsys.Open(filepath.ToSlash(name))
+		}
+	}
+	match, err := ctxt.MatchFile(dir, name)
+	return err == nil && match
+}
+
+// reports whether the go tool skips a directory when matching "./...", the
+// directory a walk starts from is never skipped
+func isIgnoredDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
+
+// guesses the name of a package from its import path, e.g. "uuid" for
+// "github.com/satori/go.uuid" and "pgtype" for "github.com/jackc/pgx/v5/pgtype"
+func guessPackageName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimPrefix(name, "go.")
+	name = strings.TrimSuffix(name, "-go")
+	name = strings.TrimSuffix(name, ".go")
+	if idx := strings.LastIndexAny(name, ".-"); idx != -1 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// reports whether a path element is a major version suffix such as "v2"
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the module path declared in a go.mod file
+func modulePath(gomod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			rest, _, _ = strings.Cut(rest, "//")
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// a TypeScript type a go type is mapped to
+type TSType struct {
+	// the TypeScript type, e.g. "string" or "Decimal"
+	Type string
+	// module the type is imported from, e.g. "decimal.js", empty for types
+	// that need no import
+	From string
+}
+
+// maps go types to TypeScript types, ok is false for the types it does not handle
+type TypeMapper interface {
+	MapType(goType types.Type) (tsType TSType, ok bool)
+}
+
+// adapts a function to the TypeMapper interface
+type TypeMapperFunc func(goType types.Type) (TSType, bool)
+
+func (f TypeMapperFunc) MapType(goType types.Type) (TSType, bool) {
+	return f(goType)
+}
+
+// a struct field as a FieldMapper sees it
+type Field struct {
+	Name string // the go name of the field
+	Type types.Type
+	Tags map[string]StructTag // every key of the struct tag, e.g. "json", "yaml" or "db"
+}
+
+// maps struct fields to TypeScript types, ok is false for the fields it does not handle
+type FieldMapper interface {
+	MapField(field Field) (tsType TSType, ok bool)
+}
+
+// adapts a function to the FieldMapper interface
+type FieldMapperFunc func(field Field) (TSType, bool)
+
+func (f FieldMapperFunc) MapField(field Field) (TSType, bool) {
+	return f(field)
+}
+
+// maps go types by their qualified name, either "import/path.Name",
+// "pkg.Name" or the name of a basic type such as "int64"
+type typeMappings map[string]TSType
+
+func (m typeMappings) MapType(goType types.Type) (TSType, bool) {
+	var obj *types.TypeName
+	switch t := goType.(type) {
+	case *types.Basic:
+		tsType, ok := m[t.Name()]
+		return tsType, ok
+	case *types.Alias:
+		obj = t.Obj()
+	case *types.Named:
+		obj = t.Origin().Obj()
+	default:
+		return TSType{}, false
+	}
+
+	if obj.Pkg() == nil {
+		tsType, ok := m[obj.Name()]
+		return tsType, ok
+	}
+	if tsType, ok := m[obj.Pkg().Path()+"."+obj.Name()]; ok {
+		return tsType, true
+	}
+	tsType, ok := m[obj.Pkg().Name()+"."+obj.Name()]
+	return tsType, ok
+}
+
+// maps a go type with the registered mappings, then with the mappers, the
+// mappers registered last first. A mapping of a type always takes precedence
+// over the mappers, whatever order they were registered in
+func (g *Generator) mapType(goType types.Type) (string, bool) {
+	if tsType, ok := g.typeMappings.MapType(goType); ok {
+		return g.useTSType(tsType), true
+	}
+	for i := len(g.typeMappers) - 1; i >= 0; i-- {
+		if tsType, ok := g.typeMappers[i].MapType(goType); ok {
+			return g.useTSType(tsType), true
+		}
+	}
+	return "", false
+}
+
+// maps a struct field with the registered field mappers, the mappers
+// registered last first
+func (g *Generator) mapField(field FieldInfo) (TSType, bool) {
+	for i := len(g.fieldMappers) - 1; i >= 0; i-- {
+		if tsType, ok := g.fieldMappers[i].MapField(Field{Name: field.Name, Type: field.GoType, Tags: field.Tags}); ok {
+			return tsType, true
+		}
+	}
+	return TSType{}, false
+}
+
+// records the import a mapped type needs and returns the type
+func (g *Generator) useTSType(tsType TSType) string {
+	if tsType.From == "" {
+		return tsType.Type
+	}
+
+	name := tsType.Type
+	if idx := strings.IndexAny(name, "<[ |"); idx != -1 {
+		name = name[:idx]
+	}
+	g.addImport(tsType.From, name, false)
+	return tsType.Type
+}
+
+// records an imported name, value is false for names only used as types
+func (g *Generator) addImport(module, name string, value bool) {
+	if g.imports[module] == nil {
+		g.imports[module] = make(map[string]bool)
+	}
+	g.imports[module][name] = g.imports[module][name] || value
+}
+
+// generates the imports of the mapped types and declarations of other
+// modules that were used
+func (g *Generator) generateImports() string {
+	modules := make([]string, 0, len(g.imports))
+	for module := range g.imports {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	var sb strings.Builder
+	for _, module := range modules {
+		names := make([]string, 0, len(g.imports[module]))
+		hasValues := false
+		for name, value := range g.imports[module] {
+			names = append(names, name)
+			hasValues = hasValues || value
+		}
+		sort.Strings(names)
+
+		// values such as zod schemas need a regular import
+		if hasValues {
+			for i, name := range names {
+				if !g.imports[module][name] {
+					names[i] = "type " + name
+				}
+			}
+			sb.WriteString("import { " + strings.Join(names, ", ") + " } from " + tsStringLiteral(module) + ";\n")
+			continue
+		}
+		sb.WriteString("import type { " + strings.Join(names, ", ") + " } from " + tsStringLiteral(module) + ";\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// a TypeScript module generated for a go package
+type tsModule struct {
+	pkgPath string
+	pkgName string
+	file    string // path relative to the output directory, without extension
+	enums   []EnumInfo
+	structs []StructInfo
+
+	// local names of the declarations and imports, and the local name of
+	// every imported declaration by module and name
+	names    map[string]bool
+	imported map[string]map[string]string
+}
+
+// groups the parsed structs and enums by package, in the order they were parsed
+func (g *Generator) collectModules() ([]*tsModule, error) {
+	var modules []*tsModule
+	g.modules = make(map[string]*tsModule)
+	module := func(pkgPath, pkgName string) *tsModule {
+		if m, ok := g.modules[pkgPath]; ok {
+			return m
+		}
+		m := &tsModule{
+			pkgPath:  pkgPath,
+			pkgName:  pkgName,
+			names:    make(map[string]bool),
+			imported: make(map[string]map[string]string),
+		}
+		g.modules[pkgPath] = m
+		modules = append(modules, m)
+		return m
+	}
+
+	for _, enumInfo := range g.parser.parseResult.Enums {
+		m := module(enumInfo.PkgPath, enumInfo.Package)
+		m.enums = append(m.enums, enumInfo)
+		m.declare(enumInfo.Name)
+	}
+	for _, structInfo := range g.parser.parseResult.Structs {
+		m := module(structInfo.PkgPath, structInfo.Package)
+		m.structs = append(m.structs, structInfo)
+		m.declare(structInfo.Name)
+	}
+
+	if err := g.moduleFiles(modules); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// lays the modules out like the packages below the input directory, packages
+// loaded by import path are laid out below their common import path
+func (g *Generator) moduleFiles(modules []*tsModule) error {
+	var inputDir string
+	if g.inputDir != "" {
+		var err error
+		if inputDir, err = filepath.Abs(g.inputDir); err != nil {
+			return err
+		}
+	}
+
+	var rest []*tsModule
+	for _, m := range modules {
+		m.file = m.pkgName
+		dir, ok := g.parser.loader.localDir(m.pkgPath)
+		if inputDir != "" && ok {
+			if rel, err := filepath.Rel(inputDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				if rel != "." {
+					m.file = filepath.ToSlash(rel)
+				}
+				continue
+			}
+		}
+		rest = append(rest, m)
+	}
+
+	if len(rest) >= 2 {
+		root := rest[0].pkgPath
+		for _, m := range rest[1:] {
+			for root != "." && root != "/" && m.pkgPath != root && !strings.HasPrefix(m.pkgPath, root+"/") {
+				root = path.Dir(root)
+			}
+		}
+		for _, m := range rest {
+			if rel, ok := strings.CutPrefix(m.pkgPath, root+"/"); ok {
+				m.file = rel
+			}
+		}
+	}
+
+	// packages that would share a file, such as net/url and example.com/app/url
+	// named after their package, are laid out by their full import path
+	for _, same := range sameFiles(modules) {
+		for _, m := range same {
+			m.file = m.pkgPath
+		}
+	}
+	if same := sameFiles(modules); len(same) > 0 {
+		return fmt.Errorf("packages %s and %s would both be written to %s.ts", same[0][0].pkgPath, same[0][1].pkgPath, same[0][0].file)
+	}
+	return nil
+}
+
+// returns the modules that share a file, in the order they were parsed
+func sameFiles(modules []*tsModule) [][]*tsModule {
+	var files []string
+	byFile := make(map[string][]*tsModule)
+	for _, m := range modules {
+		if _, ok := byFile[m.file]; !ok {
+			files = append(files, m.file)
+		}
+		byFile[m.file] = append(byFile[m.file], m)
+	}
+
+	var same [][]*tsModule
+	for _, file := range files {
+		if len(byFile[file]) > 1 {
+			same = append(same, byFile[file])
+		}
+	}
+	return same
+}
+
+// reserves the local names of a declaration
+func (m *tsModule) declare(name string) {
+	m.names[name] = true
+	m.names[name+"Schema"] = true
+}
+
+// generates one TypeScript module per go package and an index.ts re-exporting them
+func (g *Generator) generateModules() ([]generatedFile, error) {
+	modules, err := g.collectModules()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { g.module = nil }()
+
+	var files []generatedFile
+	for _, module := range modules {
+		g.module = module
+		ts := g.generateOutput(module.enums, module.structs)
+
+		file := filepath.Join(g.outputDir, filepath.FromSlash(module.file)+".ts")
+		files = append(files, generatedFile{path: file, content: []byte(ts), kind: "output file"})
+	}
+
+	// modules whose names collide are re-exported as namespaces
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].file < modules[j].file
+	})
+	var sb strings.Builder
+	sb.WriteString("/* Do not change, this code is generated from Golang structs */\n\n")
+	for _, module := range modules {
+		if namespace := g.namespaces[module.pkgPath]; namespace != "" {
+			sb.WriteString("export * as " + namespace + " from " + tsStringLiteral("./"+module.file) + ";\n")
+			continue
+		}
+		sb.WriteString("export * from " + tsStringLiteral("./"+module.file) + ";\n")
+	}
+	files = append(files, generatedFile{path: filepath.Join(g.outputDir, "index.ts"), content: []byte(sb.String()), kind: "index file"})
+	return files, nil
+}
+
+// returns the local name of a declaration of a scanned package, importing it
+// when it is declared in another module. Names that are taken are imported
+// under an alias prefixed with the package name, e.g. CommonUser.
+func (g *Generator) declarationName(pkgPath, name string, value bool) string {
+	if g.module == nil {
+		if namespace := g.namespaces[pkgPath]; namespace != "" {
+			return namespace + "." + name
+		}
+		return name
+	}
+	if pkgPath == g.module.pkgPath {
+		return name
+	}
+	target, ok := g.modules[pkgPath]
+	if !ok {
+		return name
+	}
+
+	from := relativeModule(g.module.file, target.file)
+	if local, ok := g.module.imported[from][name]; ok {
+		return local
+	}
+
+	local := name
+	if g.module.names[local] {
+		local = exportedName(target.pkgName) + name
+		for i := 2; g.module.names[local]; i++ {
+			local = fmt.Sprintf("%s%s%d", exportedName(target.pkgName), name, i)
+		}
+	}
+	g.module.names[local] = true
+	if g.module.imported[from] == nil {
+		g.module.imported[from] = make(map[string]string)
+	}
+	g.module.imported[from][name] = local
+
+	imported := name
+	if local != name {
+		imported = name + " as " + local
+	}
+	g.addImport(from, imported, value)
+	return local
+}
+
+// returns the import path of a module relative to another, e.g. "../common"
+func relativeModule(from, to string) string {
+	rel, err := filepath.Rel(path.Dir(from), to)
+	if err != nil {
+		return "./" + to
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, ".") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// capitalizes a package name to prefix a TypeScript name with
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loads packages by go package patterns, e.g. "./..." or
+// "github.com/org/api/models", resolved by the go command so that go.mod,
+// build tags and GOFLAGS are honoured
+func (p *Parser) FromPackages(patterns ...string) error {
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Fset: p.loader.fset,
+	}
+	if tags := p.loader.build.BuildTags; len(tags) > 0 {
+		config.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
+	}
+	pkgs, err := packages.Load(config, patterns...)
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		// type errors are ignored like they are for directories, a package
+		// that could not be found or parsed is not
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind != packages.TypeError {
+				return fmt.Errorf("failed to load %s: %w", pkg.PkgPath, pkgErr)
+			}
+		}
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+
+		dir := filepath.Dir(pkg.GoFiles[0])
+		p.loader.dirPaths[dir] = pkg.PkgPath
+		p.parsePackage(&loadedPackage{
+			dir:   dir,
+			path:  pkg.PkgPath,
+			files: pkg.Syntax,
+			types: pkg.Types,
+			info:  pkg.TypesInfo,
+		})
+	}
+	p.markEnumFields()
+	return nil
+}
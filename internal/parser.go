@@ -1,13 +1,13 @@
 package internal
 
 import (
-	"os"
-	"path/filepath"
+	"io/fs"
 	"strings"
 
 	"go/ast"
-	"go/parser"
+	"go/constant"
 	"go/token"
+	"go/types"
 )
 
 type ParseResult struct {
@@ -17,161 +17,488 @@ type ParseResult struct {
 
 type Parser struct {
 	parseResult *ParseResult
+	loader      *loader
+
+	// lookups of the parsed structs and enums by their type
+	structIndex map[*types.TypeName]int
+	enumIndex   map[*types.TypeName]int
+
+	// struct tag keys fields are named by, in order of preference
+	tagKeys []string
+
+	// directives and doc comments of the type declarations
+	typeDirectives map[*types.TypeName]directives
+	typeDocs       map[*types.TypeName]string
+
+	// structs of struct types that have no declaration of their own, such as
+	// inline structs or structs of packages that were not scanned
+	typeStructs map[*types.Struct]*StructInfo
 }
 
 func NewParser() *Parser {
 	return &Parser{
 		parseResult: &ParseResult{},
+		loader:      newLoader(token.NewFileSet()),
+		structIndex: make(map[*types.TypeName]int),
+		enumIndex:   make(map[*types.TypeName]int),
+		tagKeys:     []string{"json"},
+
+		typeDirectives: make(map[*types.TypeName]directives),
+		typeDocs:       make(map[*types.TypeName]string),
+		typeStructs:    make(map[*types.Struct]*StructInfo),
 	}
 }
 
+// forgets the parsed structs and enums so that the parser can be run again,
+// the options are kept
+func (p *Parser) reset() {
+	p.parseResult = &ParseResult{}
+	p.loader.reset()
+	p.structIndex = make(map[*types.TypeName]int)
+	p.enumIndex = make(map[*types.TypeName]int)
+	p.typeDirectives = make(map[*types.TypeName]directives)
+	p.typeDocs = make(map[*types.TypeName]string)
+	p.typeStructs = make(map[*types.Struct]*StructInfo)
+}
+
+// goes through all the go files below root in a file system, such as an
+// embed.FS or a zip archive, and parses them
+func (p *Parser) FromFS(fsys fs.FS, root string) error {
+	p.loader.fsys = fsys
+	defer func() { p.loader.fsys = nil }()
+	return p.FromDir(root)
+}
+
 // goes through all the go files in the directory and parses them
 func (p *Parser) FromDir(dir string) error {
-	fset := token.NewFileSet()
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	p.loader.findModule(dir)
+
+	var dirs []string
+	err := p.loader.walkDirs(dir, func(path string) {
+		p.loader.addDir(dir, path)
+		dirs = append(dirs, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		packages, err := p.loader.loadDir(dir)
 		if err != nil {
 			return err
 		}
-		if info.IsDir() || !strings.HasSuffix(path, ".go") {
-			return nil
-		}
-		if strings.HasSuffix(path, "_test.go") {
-			return nil
+		for _, pkg := range packages {
+			p.parsePackage(pkg)
 		}
-		fileResult, err := p.parseFile(fset, path)
-		if err != nil {
-			return err
-		}
-		p.parseResult.Enums = append(p.parseResult.Enums, fileResult.Enums...)
-		p.parseResult.Structs = append(p.parseResult.Structs, fileResult.Structs...)
-		return nil
-	})
-	if err != nil {
-		return err
 	}
+	p.markEnumFields()
 	return nil
 }
 
-// parses a single go file
-func (p *Parser) parseFile(fset *token.FileSet, path string) (*ParseResult, error) {
-	result := &ParseResult{}
-
-	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
-	if err != nil {
-		return nil, err
+// collects the structs and enums declared in a type-checked package
+func (p *Parser) parsePackage(pkg *loadedPackage) {
+	if pkg.types == nil {
+		return
 	}
 
-	for _, decl := range file.Decls {
-		genDecl, ok := decl.(*ast.GenDecl)
-		if !ok {
-			continue
-		}
-		if genDecl.Tok == token.TYPE {
+	// directives and docs of all types first, the constants of an enum may
+	// be declared before its type
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
 			for _, spec := range genDecl.Specs {
-				typeSpec, ok := spec.(*ast.TypeSpec)
-				if !ok {
-					continue
-				}
-				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
-					result.Structs = append(result.Structs, *p.parseStruct(typeSpec.Name.Name, file.Name.Name, structType))
+				typeSpec := spec.(*ast.TypeSpec)
+				if obj, ok := pkg.info.Defs[typeSpec.Name].(*types.TypeName); ok {
+					doc := typeSpecDoc(genDecl, typeSpec)
+					p.typeDirectives[obj] = parseDirectives(doc)
+					p.typeDocs[obj] = docText(doc)
 				}
 			}
 		}
 	}
 
-	return result, nil
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			if genDecl.Tok == token.TYPE {
+				for _, spec := range genDecl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					obj, _ := pkg.info.Defs[typeSpec.Name].(*types.TypeName)
+					typeDirectives := p.typeDirectives[obj]
+					if typeDirectives.has("ignore") {
+						continue
+					}
+
+					structInfo := p.parseStruct(pkg, typeSpec.Name.Name, structType)
+					structInfo.Doc = p.typeDocs[obj]
+					if name := typeDirectives["name"]; name != "" {
+						structInfo.Name = name
+					}
+					if typeDirectives.has("readonly") {
+						for i := range structInfo.Fields {
+							structInfo.Fields[i].IsReadonly = true
+						}
+					}
+					if obj != nil {
+						if named, ok := obj.Type().(*types.Named); ok {
+							structInfo.GoType = named
+							p.structIndex[obj] = len(p.parseResult.Structs)
+						}
+					}
+					p.parseResult.Structs = append(p.parseResult.Structs, *structInfo)
+				}
+			}
+			if genDecl.Tok == token.CONST {
+				p.parseConstBlock(pkg, genDecl)
+			}
+		}
+	}
 }
 
 // parses a single struct
-func (p *Parser) parseStruct(name, pkgName string, structType *ast.StructType) *StructInfo {
+func (p *Parser) parseStruct(pkg *loadedPackage, name string, structType *ast.StructType) *StructInfo {
 	result := &StructInfo{
 		Name:    name,
-		Package: pkgName,
+		Package: pkg.types.Name(),
+		PkgPath: pkg.path,
 		Fields:  make([]FieldInfo, 0),
 	}
-	for _, field := range structType.Fields.List {
+	if t, ok := pkg.info.TypeOf(structType).(*types.Struct); ok {
+		p.typeStructs[t] = result
+	}
 
-		// Embedded field
-		if len(field.Names) == 0 {
-			continue
+	for _, field := range structType.Fields.List {
+		goType := pkg.info.TypeOf(field.Type)
+		if goType == nil {
+			goType = types.Typ[types.Invalid]
 		}
 
-		fieldInfo := FieldInfo{
-			Name:    field.Names[0].Name,
-			Type:    p.typeToString(field.Type),
-			JSONTag: "",
+		names := make([]string, 0, len(field.Names))
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
 		}
-
-		if strings.HasPrefix(fieldInfo.Type, "*") {
-			fieldInfo.IsPointer = true
-			fieldInfo.IsOptional = true
+		if len(names) == 0 {
+			// Embedded field, named after its type
+			names = append(names, embeddedFieldName(field.Type))
 		}
 
-		baseType := strings.TrimPrefix(fieldInfo.Type, "*")
-		baseType = strings.TrimPrefix(baseType, "[]")
-
-		if field.Tag != nil {
-			tag := strings.Trim(field.Tag.Value, "`")
-			name, hasOmitEmpty := p.parseJSONTagFull(tag)
-			if name != "" {
-				fieldInfo.JSONTag = name
+		for _, fieldName := range names {
+			fieldInfo := FieldInfo{
+				Name:       fieldName,
+				Type:       p.typeString(goType, field.Type),
+				GoType:     goType,
+				JSONTag:    "",
+				IsEmbedded: len(field.Names) == 0,
+				Doc:        docText(field.Doc, field.Comment),
 			}
 
-			if hasOmitEmpty {
-				fieldInfo.IsOptional = true
+			tag := ""
+			if field.Tag != nil {
+				tag = strings.Trim(field.Tag.Value, "`")
 			}
-		}
+			if !p.applyTag(&fieldInfo, tag) {
+				continue
+			}
+			if !parseDirectives(field.Doc, field.Comment).applyField(&fieldInfo) {
+				continue
+			}
+
+			// inline structs, possibly nested in slices, maps or pointers
+			ast.Inspect(field.Type, func(node ast.Node) bool {
+				inline, ok := node.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				structInfo := p.parseStruct(pkg, fieldName, inline)
+				if inline == field.Type {
+					fieldInfo.EmbeddedStruct = structInfo
+				}
+				return false
+			})
 
-		if _, ok := field.Type.(*ast.StructType); ok {
-			fieldInfo.EmbeddedStruct = p.parseStruct(field.Names[0].Name, pkgName, field.Type.(*ast.StructType))
+			result.Fields = append(result.Fields, fieldInfo)
 		}
+	}
+	return result
+}
 
+// returns the struct of a struct type that has no declaration of its own
+func (p *Parser) typeStruct(t *types.Struct, name string, pkg *types.Package) *StructInfo {
+	if structInfo, ok := p.typeStructs[t]; ok {
+		return structInfo
+	}
+
+	result := &StructInfo{
+		Name:   name,
+		Fields: make([]FieldInfo, 0, t.NumFields()),
+	}
+	if pkg != nil {
+		result.Package = pkg.Name()
+		result.PkgPath = pkg.Path()
+	}
+	p.typeStructs[t] = result
+
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		fieldInfo := FieldInfo{
+			Name:       field.Name(),
+			Type:       p.typeString(field.Type(), nil),
+			GoType:     field.Type(),
+			IsEmbedded: field.Embedded(),
+		}
+		if !p.applyTag(&fieldInfo, t.Tag(i)) {
+			continue
+		}
 		result.Fields = append(result.Fields, fieldInfo)
 	}
 	return result
 }
 
-// converts a go type to a string
-func (p *Parser) typeToString(expr ast.Expr) string {
+// sets the pointer and json tag information of a field, returns false for
+// fields encoding/json always skips
+func (p *Parser) applyTag(fieldInfo *FieldInfo, tag string) bool {
+	if _, ok := types.Unalias(fieldInfo.GoType).(*types.Pointer); ok {
+		fieldInfo.IsPointer = true
+		fieldInfo.IsOptional = true
+	}
+
+	fieldInfo.Tags = parseStructTag(tag)
+	jsonTag := parseJSONTag(fieldInfo.Tags, p.tagKeys)
+	if jsonTag.Name == "" && !fieldInfo.IsEmbedded && lowercasesNames(p.tagKeys[0]) {
+		jsonTag.Name = strings.ToLower(fieldInfo.Name)
+	}
+
+	// encoding/json skips embedded fields tagged with "-"
+	if fieldInfo.IsEmbedded && jsonTag.Ignored {
+		return false
+	}
+
+	fieldInfo.JSONTag = jsonTag.Name
+	fieldInfo.IsIgnored = jsonTag.Ignored
+	fieldInfo.IsQuoted = jsonTag.Quoted
+	if jsonTag.OmitEmpty {
+		fieldInfo.IsOptional = true
+	}
+
+	// validate:"required" is stronger than omitempty
+	fieldInfo.Validate = parseValidateTag(fieldInfo.Tags["validate"].Value)
+	if fieldInfo.Validate.Required {
+		fieldInfo.IsOptional = false
+	}
+	return true
+}
+
+// returns the go type as written in the source, with package names as qualifiers
+func (p *Parser) typeString(goType types.Type, expr ast.Expr) string {
+	if basic, ok := goType.(*types.Basic); ok && basic.Kind() == types.Invalid && expr != nil {
+		return types.ExprString(expr)
+	}
+	return types.TypeString(goType, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
+
+// returns the implicit name of an embedded field, e.g. "User" for "*models.User"
+func embeddedFieldName(expr ast.Expr) string {
 	switch t := expr.(type) {
 	case *ast.Ident:
 		return t.Name
-	case *ast.ArrayType:
-		return "[]" + p.typeToString(t.Elt)
 	case *ast.StarExpr:
-		return "*" + p.typeToString(t.X)
+		return embeddedFieldName(t.X)
 	case *ast.SelectorExpr:
-		return p.typeToString(t.X) + "." + t.Sel.Name
-	case *ast.MapType:
-		return "map[" + p.typeToString(t.Key) + "]" + p.typeToString(t.Value)
-	case *ast.StructType:
-		return "struct"
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedFieldName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(t.X)
 	default:
-		return "any"
+		return ""
 	}
 }
 
-// parses a json tag and returns the name and if it has omitempty
-func (p *Parser) parseJSONTagFull(tag string) (name string, hasOmitEmpty bool) {
-	for _, part := range strings.Split(tag, " ") {
-		if strings.HasPrefix(part, "json:") {
-			value := strings.TrimPrefix(part, "json:")
-			value = strings.Trim(value, "\"")
+// collects the constants of a const block whose type is a named integer or
+// string type of the package as enum values
+func (p *Parser) parseConstBlock(pkg *loadedPackage, genDecl *ast.GenDecl) {
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for _, name := range valueSpec.Names {
+			if name.Name == "_" {
+				continue
+			}
+			obj, ok := pkg.info.Defs[name].(*types.Const)
+			if !ok {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok || named.Obj().Pkg() != pkg.types {
+				continue
+			}
+			basic, ok := named.Underlying().(*types.Basic)
+			if !ok {
+				continue
+			}
 
-			// Check for omitempty
-			if strings.Contains(value, "omitempty") {
-				hasOmitEmpty = true
+			enumValue := EnumValue{Name: name.Name, Doc: docText(valueSpec.Doc, valueSpec.Comment)}
+			isString := basic.Info()&types.IsString != 0
+			switch {
+			case isString:
+				enumValue.StringValue = constant.StringVal(obj.Val())
+			case basic.Info()&types.IsInteger != 0:
+				intValue, ok := constant.Int64Val(obj.Val())
+				if !ok {
+					continue
+				}
+				enumValue.Value = int(intValue)
+			default:
+				continue
 			}
 
-			// Get just the name, not options like omitempty
-			if idx := strings.Index(value, ","); idx != -1 {
-				value = value[:idx]
+			typeDirectives := p.typeDirectives[named.Obj()]
+			if typeDirectives.has("ignore") {
+				continue
 			}
-			if value != "-" {
-				name = value
+			idx, ok := p.enumIndex[named.Obj()]
+			if !ok {
+				enumName := named.Obj().Name()
+				if name := typeDirectives["name"]; name != "" {
+					enumName = name
+				}
+				idx = len(p.parseResult.Enums)
+				p.enumIndex[named.Obj()] = idx
+				p.parseResult.Enums = append(p.parseResult.Enums, EnumInfo{
+					Name:     enumName,
+					Package:  pkg.types.Name(),
+					PkgPath:  pkg.path,
+					IsString: isString,
+					GoType:   named,
+					Doc:      p.typeDocs[named.Obj()],
+				})
 			}
-			return name, hasOmitEmpty
+			p.parseResult.Enums[idx].Values = append(p.parseResult.Enums[idx].Values, enumValue)
+		}
+	}
+}
+
+// marks the fields whose type is a known enum
+func (p *Parser) markEnumFields() {
+	for _, structInfo := range p.typeStructs {
+		p.markStructEnumFields(structInfo)
+	}
+	for i := range p.parseResult.Structs {
+		p.markStructEnumFields(&p.parseResult.Structs[i])
+	}
+}
+
+// marks the fields of a struct whose type is a known enum
+func (p *Parser) markStructEnumFields(structInfo *StructInfo) {
+	for i := range structInfo.Fields {
+		field := &structInfo.Fields[i]
+		enumInfo, ok := p.lookupEnum(baseType(field.GoType))
+		field.IsEnum = ok
+		field.EnumType = ""
+		if ok {
+			field.EnumType = enumInfo.Name
+		}
+	}
+}
+
+// returns the parsed struct of a named type
+func (p *Parser) lookupStruct(t types.Type) (*StructInfo, bool) {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	idx, ok := p.structIndex[named.Origin().Obj()]
+	if !ok {
+		return nil, false
+	}
+	return &p.parseResult.Structs[idx], true
+}
+
+// returns the parsed enum of a named type
+func (p *Parser) lookupEnum(t types.Type) (*EnumInfo, bool) {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	idx, ok := p.enumIndex[named.Obj()]
+	if !ok {
+		return nil, false
+	}
+	return &p.parseResult.Enums[idx], true
+}
+
+// how encoding/json writes a type that marshals itself
+type marshaler int
+
+const (
+	notMarshaler marshaler = iota
+	jsonMarshaler
+	textMarshaler
+)
+
+// reports whether a named type implements json.Marshaler or
+// encoding.TextMarshaler, with value or pointer receivers
+func (p *Parser) marshalerOf(named *types.Named) marshaler {
+	if types.IsInterface(named) {
+		return notMarshaler
+	}
+	if hasMarshalMethod(named, "MarshalJSON") {
+		return jsonMarshaler
+	}
+	if hasMarshalMethod(named, "MarshalText") {
+		return textMarshaler
+	}
+	return notMarshaler
+}
+
+// reports whether the pointer method set of a type has a method with the
+// signature func() ([]byte, error)
+func hasMarshalMethod(named *types.Named, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, named.Obj().Pkg(), name)
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	signature := method.Signature()
+	if signature.Params().Len() != 0 || signature.Results().Len() != 2 {
+		return false
+	}
+	bytes, ok := signature.Results().At(0).Type().Underlying().(*types.Slice)
+	if !ok || !isByteType(bytes.Elem()) {
+		return false
+	}
+	return types.Identical(signature.Results().At(1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// strips the pointers, slices and arrays around a type
+func baseType(t types.Type) types.Type {
+	for {
+		switch u := types.Unalias(t).(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		default:
+			return t
 		}
 	}
-	return "", false
 }
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parses a struct tag following the reflect.StructTag conventions: keys
+// separated by spaces, each followed by a colon and a quoted value. Parsing
+// stops at the first malformed key, like reflect.StructTag.Lookup.
+func parseStructTag(tag string) map[string]StructTag {
+	tags := make(map[string]StructTag)
+	for tag != "" {
+		// skip leading space
+		i := 0
+		for i < len(tag) && tag[i] <= ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// a key is a non-empty run of non-space, non-quote, non-colon characters
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// the quoted value, up to the first unescaped quote
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+
+		if _, ok := tags[key]; !ok {
+			tags[key] = newStructTag(value)
+		}
+	}
+	return tags
+}
+
+// splits the value of a struct tag key into its name and options
+func newStructTag(value string) StructTag {
+	name, options, _ := strings.Cut(value, ",")
+	structTag := StructTag{Value: value, Name: name}
+	if options != "" {
+		structTag.Options = strings.Split(options, ",")
+	}
+	return structTag
+}
+
+// the parts of a json struct tag
+type jsonTag struct {
+	Name      string
+	Ignored   bool // json:"-"
+	OmitEmpty bool
+	Quoted    bool // json:",string"
+}
+
+// reads the naming tag of the parsed struct tags, the first of the keys the
+// field is tagged with is used, e.g. "bson" and then "json"
+func parseJSONTag(tags map[string]StructTag, keys []string) jsonTag {
+	for _, key := range keys {
+		tag, ok := tags[key]
+		if !ok {
+			continue
+		}
+
+		// "-" skips the field, while "-," names it "-"
+		if tag.Value == "-" {
+			return jsonTag{Ignored: true}
+		}
+		return jsonTag{
+			Name:      tag.Name,
+			OmitEmpty: tag.HasOption("omitempty"),
+			Quoted:    tag.HasOption("string"),
+		}
+	}
+	return jsonTag{}
+}
+
+// reports whether fields without a name in a tag key are named after the
+// lowercased field name, as the bson and yaml encoders do
+func lowercasesNames(key string) bool {
+	return key == "bson" || key == "yaml"
+}
@@ -1,33 +1,139 @@
 package internal
 
-// represents an enum (TODO)
+import (
+	"go/types"
+	"slices"
+)
+
+// represents an enum declared as a typed const block
 type EnumInfo struct {
-	Name    string
-	Package string
-	Values  []EnumValue
+	Name     string
+	Package  string
+	PkgPath  string
+	IsString bool
+	Values   []EnumValue
+	GoType   *types.Named
+	Doc      string
 }
 
-// represents a value of an enum (TODO)
+// represents a value of an enum, StringValue is used for string enums
 type EnumValue struct {
-	Name  string
-	Value int
+	Name        string
+	Value       int
+	StringValue string
+	Doc         string
+}
+
+// controls how embedded structs are written
+type EmbedStyle int
+
+const (
+	// promote the embedded fields into the parent, like encoding/json does
+	EmbedFlatten EmbedStyle = iota
+	// export interface Admin extends User { ... };
+	EmbedExtends
+)
+
+// controls how string enums are written
+type StringEnumStyle int
+
+const (
+	// export type Role = "admin" | "editor";
+	StringEnumUnion StringEnumStyle = iota
+	// export enum Role { Admin = "admin", Editor = "editor" };
+	StringEnumEnum
+	// export const Role = { Admin: "admin", Editor: "editor" } as const;
+	StringEnumConst
+)
+
+// controls what is generated
+type OutputMode int
+
+const (
+	// export interface User { ... };
+	OutputTypeScript OutputMode = iota
+	// export const UserSchema = z.object({ ... });
+	// export type User = z.infer<typeof UserSchema>;
+	OutputZod
+)
+
+// controls how structs and enums of different packages that share a name are told apart
+type CollisionStrategy int
+
+const (
+	// fail with the positions of both declarations
+	CollisionError CollisionStrategy = iota
+	// prefix the names with their package name, e.g. BillingAccount
+	CollisionPrefix
+	// wrap the declarations of the packages in namespaces, e.g. export namespace billing { ... }
+	CollisionNamespace
+)
+
+// a struct or enum generated because it is reachable from a root, From and
+// Field name the struct field it was reached through and are empty for roots
+type Reference struct {
+	Name    string
+	PkgPath string
+	From    string
+	Field   string
+}
+
+func (r Reference) String() string {
+	if r.From == "" {
+		return r.Name + " is a root"
+	}
+	return r.Name + " is referenced by " + r.From + "." + r.Field
 }
 
 // represents a struct
 type StructInfo struct {
 	Name    string
 	Package string
+	PkgPath string
 	Fields  []FieldInfo
+	GoType  *types.Named // nil for inline structs
+	Doc     string
 }
 
 // represents a field of a struct
 type FieldInfo struct {
 	Name           string
 	Type           string
-	JSONTag        string
+	GoType         types.Type
+	JSONTag        string // json key, or the name set with //gotots:name
+	TSType         string // TypeScript type set with //gotots:type
 	IsEnum         bool
 	EnumType       string
 	IsOptional     bool
 	IsPointer      bool
+	IsEmbedded     bool // anonymous field whose fields are promoted into the parent
+	IsIgnored      bool // tagged json:"-"
+	IsQuoted       bool // tagged json:",string"
+	IsReadonly     bool // marked //gotots:readonly, or its struct is
 	EmbeddedStruct *StructInfo
+	Doc            string // doc and line comment
+	Validate       ValidateRules
+	Tags           map[string]StructTag // every key of the struct tag, e.g. "json", "yaml" or "db"
+}
+
+// the value of a struct tag key, e.g. "name,omitempty" for json:"name,omitempty"
+type StructTag struct {
+	Value   string
+	Name    string   // the part before the first comma
+	Options []string // the comma separated parts after the name
+}
+
+// reports whether the tag has an option, e.g. "omitempty"
+func (t StructTag) HasOption(option string) bool {
+	return slices.Contains(t.Options, option)
+}
+
+// rules of a go-playground/validator tag, e.g. validate:"required,min=3"
+type ValidateRules struct {
+	Required bool
+	OneOf    []string
+	Min      string // as written, a length for strings and slices
+	Max      string
+	Len      string
+	Format   string // "email", "url" or "uuid"
 }
@@ -0,0 +1,280 @@
+package internal
+
+import (
+	"encoding/json"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// parses the rules of a go-playground/validator tag that carry over to the
+// generated types. Rules after "dive" apply to elements and alternatives
+// such as "email|url" can not be expressed, both are left out.
+func parseValidateTag(tag string) ValidateRules {
+	var rules ValidateRules
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			break
+		}
+		if strings.Contains(rule, "|") {
+			continue
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			rules.Required = true
+		case "oneof":
+			rules.OneOf = parseOneOf(param)
+		case "min", "gte":
+			rules.Min = param
+		case "max", "lte":
+			rules.Max = param
+		case "len":
+			rules.Len = param
+		case "email":
+			rules.Format = "email"
+		case "url", "http_url":
+			rules.Format = "url"
+		case "uuid", "uuid3", "uuid4", "uuid5":
+			rules.Format = "uuid"
+		}
+	}
+	return rules
+}
+
+// splits the values of a oneof rule, values with spaces are single quoted
+func parseOneOf(param string) []string {
+	var values []string
+	for param = strings.TrimSpace(param); param != ""; param = strings.TrimSpace(param) {
+		if rest, ok := strings.CutPrefix(param, "'"); ok {
+			value, after, _ := strings.Cut(rest, "'")
+			values = append(values, value)
+			param = after
+			continue
+		}
+		value, after, _ := strings.Cut(param, " ")
+		values = append(values, value)
+		param = after
+	}
+	return values
+}
+
+// returns the underlying type the validation rules of a field apply to, nil
+// for fields whose type has a representation of its own such as enums
+func (g *Generator) validatedType(goType types.Type) types.Type {
+	goType = types.Unalias(goType)
+	if pointer, ok := goType.(*types.Pointer); ok {
+		goType = types.Unalias(pointer.Elem())
+	}
+	if named, ok := goType.(*types.Named); ok {
+		if _, ok := g.parser.lookupEnum(named); ok {
+			return nil
+		}
+		if _, ok := g.marshaledTypeToTS(named); ok {
+			return nil
+		}
+		if obj := named.Obj(); obj.Pkg() != nil {
+			if _, ok := knownTypeToTS(obj.Pkg().Name() + "." + obj.Name()); ok {
+				return nil
+			}
+		}
+	}
+	if _, ok := g.mapType(goType); ok {
+		return nil
+	}
+
+	switch t := goType.Underlying().(type) {
+	case *types.Basic:
+		return t
+	case *types.Slice:
+		// byte slices are base64 strings
+		if isByteType(t.Elem()) {
+			return nil
+		}
+		return t
+	case *types.Array:
+		return t
+	}
+	return nil
+}
+
+// returns the basic type the validation rules of a field apply to
+func (g *Generator) validatedBasic(goType types.Type) *types.Basic {
+	basic, _ := g.validatedType(goType).(*types.Basic)
+	return basic
+}
+
+// returns the literals of a oneof rule as TypeScript literals
+func (g *Generator) oneOfLiterals(field FieldInfo) ([]string, bool) {
+	if len(field.Validate.OneOf) == 0 || field.IsQuoted {
+		return nil, false
+	}
+	basic := g.validatedBasic(field.GoType)
+	if basic == nil {
+		return nil, false
+	}
+
+	literals := make([]string, 0, len(field.Validate.OneOf))
+	for _, value := range field.Validate.OneOf {
+		switch {
+		case basic.Info()&types.IsString != 0:
+			literals = append(literals, tsStringLiteral(value))
+		case basic.Info()&(types.IsInteger|types.IsFloat) != 0:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, false
+			}
+			literals = append(literals, value)
+		default:
+			return nil, false
+		}
+	}
+	return literals, true
+}
+
+// returns the literal union of a oneof rule as TypeScript type
+func (g *Generator) oneOfToTS(field FieldInfo) (string, bool) {
+	literals, ok := g.oneOfLiterals(field)
+	if !ok {
+		return "", false
+	}
+	tsType := strings.Join(literals, " | ")
+	if field.IsPointer {
+		tsType = nullableTypeToTS(tsType)
+	}
+	return tsType, true
+}
+
+// converts the go type of a field with validation rules to a zod schema
+func (g *Generator) validatedZod(field FieldInfo, indentLevel int) (string, bool) {
+	rules := field.Validate
+	schema := ""
+	if literals, ok := g.oneOfLiterals(field); ok {
+		if g.validatedBasic(field.GoType).Info()&types.IsString != 0 {
+			schema = "z.enum([" + strings.Join(literals, ", ") + "])"
+		} else {
+			for i, literal := range literals {
+				literals[i] = "z.literal(" + literal + ")"
+			}
+			schema = literals[0]
+			if len(literals) > 1 {
+				schema = "z.union([" + strings.Join(literals, ", ") + "])"
+			}
+		}
+	} else {
+		validated := g.validatedType(field.GoType)
+		if validated == nil || rules.Min == "" && rules.Max == "" && rules.Len == "" && rules.Format == "" {
+			return "", false
+		}
+
+		goType := field.GoType
+		if pointer, ok := types.Unalias(goType).(*types.Pointer); ok {
+			goType = pointer.Elem()
+		}
+		schema = g.typeToZod(goType, indentLevel)
+
+		basic, isBasic := validated.(*types.Basic)
+		isString := isBasic && basic.Info()&types.IsString != 0
+		isNumber := isBasic && basic.Info()&(types.IsInteger|types.IsFloat) != 0
+		if !isBasic || isString || isNumber {
+			if rules.Min != "" {
+				schema += ".min(" + rules.Min + ")"
+			}
+			if rules.Max != "" {
+				schema += ".max(" + rules.Max + ")"
+			}
+		}
+		if rules.Len != "" && !isNumber {
+			schema += ".length(" + rules.Len + ")"
+		}
+		if rules.Format != "" && isString {
+			schema += "." + rules.Format + "()"
+		}
+	}
+
+	if field.IsPointer {
+		schema += ".nullable()"
+	}
+	return schema, true
+}
+
+// converts the go type of a field with validation rules to a schema
+func (g *Generator) validatedSchema(field FieldInfo) (*jsonSchema, bool) {
+	rules := field.Validate
+	var schema *jsonSchema
+	if _, ok := g.oneOfLiterals(field); ok {
+		basic := g.validatedBasic(field.GoType)
+		schema = &jsonSchema{Type: "string"}
+		for _, value := range rules.OneOf {
+			if basic.Info()&types.IsString != 0 {
+				schema.Enum = append(schema.Enum, value)
+				continue
+			}
+			schema.Type = "number"
+			if basic.Info()&types.IsInteger != 0 {
+				schema.Type = "integer"
+			}
+			schema.Enum = append(schema.Enum, json.Number(value))
+		}
+	} else {
+		validated := g.validatedType(field.GoType)
+		if validated == nil || rules.Min == "" && rules.Max == "" && rules.Len == "" && rules.Format == "" {
+			return nil, false
+		}
+
+		goType := field.GoType
+		if pointer, ok := types.Unalias(goType).(*types.Pointer); ok {
+			goType = pointer.Elem()
+		}
+		schema = g.typeSchema(goType)
+
+		basic, isBasic := validated.(*types.Basic)
+		switch {
+		case isBasic && basic.Info()&types.IsString != 0:
+			schema.MinLength = schemaInt(rules.Min)
+			schema.MaxLength = schemaInt(rules.Max)
+			if rules.Len != "" {
+				schema.MinLength = schemaInt(rules.Len)
+				schema.MaxLength = schemaInt(rules.Len)
+			}
+			switch rules.Format {
+			case "url":
+				schema.Format = "uri"
+			default:
+				schema.Format = rules.Format
+			}
+		case isBasic && basic.Info()&(types.IsInteger|types.IsFloat) != 0:
+			schema.Minimum = schemaNumber(rules.Min)
+			schema.Maximum = schemaNumber(rules.Max)
+		case !isBasic:
+			schema.MinItems = schemaInt(rules.Min)
+			schema.MaxItems = schemaInt(rules.Max)
+			if rules.Len != "" {
+				schema.MinItems = schemaInt(rules.Len)
+				schema.MaxItems = schemaInt(rules.Len)
+			}
+		}
+	}
+
+	if field.IsPointer {
+		schema = nullableSchema(schema)
+	}
+	return schema, true
+}
+
+// parses the parameter of a length rule, nil if there is none
+func schemaInt(param string) *int {
+	value, err := strconv.Atoi(param)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// parses the parameter of a value rule, empty if there is none
+func schemaNumber(param string) json.Number {
+	if _, err := strconv.ParseFloat(param, 64); err != nil {
+		return ""
+	}
+	return json.Number(param)
+}
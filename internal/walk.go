@@ -0,0 +1,152 @@
+package internal
+
+import "go/types"
+
+// how a go type is written, the TypeScript, zod and JSON Schema output all
+// write the kinds of type walkType finds
+type typeKind int
+
+const (
+	// a type with a fixed TypeScript type: mapped, well known, of a package
+	// that was not loaded or marshaling itself
+	kindFixed typeKind = iota
+	kindBasic
+	kindPointer
+	// a byte slice, encoding/json writes it as a base64 string
+	kindBytes
+	kindSlice
+	kindArray
+	kindMap
+	// an inline struct, or the struct of a package that was not scanned
+	kindStruct
+	// a parsed struct or enum, written by its name
+	kindStructRef
+	kindEnumRef
+	kindInterface
+	kindTypeParam
+	// a type of a package that was not scanned referring to itself, it can
+	// not be written out in place
+	kindRecursive
+	// channels, functions and unresolved types have no json representation
+	kindUnknown
+)
+
+// a go type classified by walkType
+type typeClass struct {
+	kind typeKind
+
+	// the TypeScript type of a fixed type and the "pkg.Name" of a well known one
+	tsType string
+	known  string
+
+	basic *types.Basic
+	param *types.TypeParam
+
+	// the element type of pointers, slices, arrays and maps, the key type of
+	// maps and the length of arrays
+	elem   types.Type
+	key    types.Type
+	length int64
+
+	// the named type of a struct or enum reference, the struct of a
+	// reference or an inline struct and the enum of a reference
+	named      *types.Named
+	structInfo *StructInfo
+	enumInfo   *EnumInfo
+}
+
+// classifies a go type and returns what emit writes for it. Named types of
+// packages that were not scanned are walked through while emit writes them,
+// so that types referring to themselves are found.
+func walkType[T any](g *Generator, goType types.Type, emit func(typeClass) T) T {
+	if tsType, ok := g.mapType(goType); ok {
+		return emit(typeClass{kind: kindFixed, tsType: tsType})
+	}
+
+	switch t := goType.(type) {
+	case *types.Alias:
+		if obj := t.Obj(); obj.Pkg() != nil {
+			name := obj.Pkg().Name() + "." + obj.Name()
+			if tsType, ok := knownTypeToTS(name); ok {
+				return emit(typeClass{kind: kindFixed, tsType: tsType, known: name})
+			}
+		}
+		return walkType(g, types.Unalias(t), emit)
+
+	case *types.Named:
+		return walkNamedType(g, t, emit)
+
+	case *types.Basic:
+		return emit(typeClass{kind: kindBasic, basic: t})
+
+	case *types.Pointer:
+		return emit(typeClass{kind: kindPointer, elem: t.Elem()})
+
+	case *types.Slice:
+		if isByteType(t.Elem()) {
+			return emit(typeClass{kind: kindBytes})
+		}
+		return emit(typeClass{kind: kindSlice, elem: t.Elem()})
+
+	case *types.Array:
+		return emit(typeClass{kind: kindArray, elem: t.Elem(), length: t.Len()})
+
+	case *types.Map:
+		return emit(typeClass{kind: kindMap, key: t.Key(), elem: t.Elem()})
+
+	case *types.Struct:
+		return emit(typeClass{kind: kindStruct, structInfo: g.parser.typeStruct(t, "", nil)})
+
+	case *types.Interface:
+		return emit(typeClass{kind: kindInterface})
+
+	case *types.TypeParam:
+		return emit(typeClass{kind: kindTypeParam, param: t})
+
+	default:
+		return emit(typeClass{kind: kindUnknown})
+	}
+}
+
+// classifies a named go type like walkType
+func walkNamedType[T any](g *Generator, named *types.Named, emit func(typeClass) T) T {
+	if structInfo, ok := g.parser.lookupStruct(named); ok {
+		return emit(typeClass{kind: kindStructRef, named: named, structInfo: structInfo})
+	}
+	if enumInfo, ok := g.parser.lookupEnum(named); ok {
+		return emit(typeClass{kind: kindEnumRef, named: named, enumInfo: enumInfo})
+	}
+
+	obj := named.Obj()
+	if obj.Pkg() != nil {
+		name := obj.Pkg().Name() + "." + obj.Name()
+		if tsType, ok := knownTypeToTS(name); ok {
+			return emit(typeClass{kind: kindFixed, tsType: tsType, known: name})
+		}
+		if g.parser.loader.isStub(obj.Pkg()) {
+			return emit(typeClass{kind: kindFixed, tsType: obj.Name()})
+		}
+	}
+	if tsType, ok := g.marshaledTypeToTS(named); ok {
+		return emit(typeClass{kind: kindFixed, tsType: tsType})
+	}
+
+	// types of packages that were not scanned are written out in place,
+	// recursive ones can not be
+	if g.expanding[named] {
+		return emit(typeClass{kind: kindRecursive})
+	}
+	g.expanding[named] = true
+	defer delete(g.expanding, named)
+
+	if structType, ok := named.Underlying().(*types.Struct); ok && obj.Pkg() != nil {
+		return emit(typeClass{kind: kindStruct, structInfo: g.parser.typeStruct(structType, obj.Name(), obj.Pkg())})
+	}
+	return walkType(g, named.Underlying(), emit)
+}
+
+// reports whether a field is written as a string, the ",string" json option
+// applies to numbers and booleans
+func (g *Generator) isQuotedField(field FieldInfo) bool {
+	return g.jsonSemantics && field.IsQuoted && isQuotableType(field.GoType)
+}
@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// how long to wait for more changes before generating, editors and
+	// formatters often write several files in a burst
+	debounceDelay = 100 * time.Millisecond
+
+	// how often the files are checked for changes when file system
+	// notifications are not available
+	pollInterval = time.Second
+)
+
+// the modification time and size of a file when it was last checked
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// generates the code, then again whenever a go file of the input changes
+// until ctx is done. report is called with the result of every run, a run
+// that fails does not stop watching. Changes are picked up with file system
+// notifications, or by polling where those are not available.
+func (g *Generator) Watch(ctx context.Context, report func(err error)) error {
+	if err := g.checkConfig(); err != nil {
+		return err
+	}
+
+	// like the files, the writer only gets the code when it changed
+	if g.outputWriter != nil {
+		writer := g.outputWriter
+		g.outputWriter = &changedWriter{w: writer}
+		defer func() { g.outputWriter = writer }()
+	}
+
+	var events chan fsnotify.Event
+	var errs chan error
+	var poll <-chan time.Time
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		events, errs = watcher.Events, watcher.Errors
+	} else {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	watched := make(map[string]bool)
+	stamps := make(map[string]fileStamp)
+	generate := func() {
+		// stamped before generating, a change while generating is picked up
+		// by the next poll
+		if poll != nil {
+			stamps = stampFiles(watched)
+		}
+		report(g.Generate())
+
+		added := make(map[string]bool)
+		for _, dir := range g.watchDirs() {
+			if watched[dir] {
+				continue
+			}
+			if watcher != nil {
+				if err := watcher.Add(dir); err != nil {
+					report(fmt.Errorf("failed to watch %s: %w", dir, err))
+					continue
+				}
+			}
+			watched[dir] = true
+			added[dir] = true
+		}
+		if poll != nil {
+			for file, stamp := range stampFiles(added) {
+				stamps[file] = stamp
+			}
+		}
+	}
+
+	generate()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event := <-events:
+			if isInputChange(event) {
+				debounce = time.After(debounceDelay)
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				delete(watched, event.Name)
+			}
+
+		case err := <-errs:
+			report(fmt.Errorf("failed to watch files: %w", err))
+
+		case <-poll:
+			if !sameStamps(stamps, stampFiles(watched)) {
+				debounce = time.After(debounceDelay)
+			}
+
+		case <-debounce:
+			debounce = nil
+			generate()
+		}
+	}
+}
+
+// a writer that drops writes of the content written before, the code of a
+// run is written at once
+type changedWriter struct {
+	w    io.Writer
+	last []byte
+}
+
+func (w *changedWriter) Write(p []byte) (int, error) {
+	if w.last != nil && bytes.Equal(p, w.last) {
+		return len(p), nil
+	}
+	n, err := w.w.Write(p)
+	if err == nil {
+		w.last = append(w.last[:0], p...)
+	}
+	return n, err
+}
+
+// returns the directories the input is read from: those below the input
+// directory, skipping the ones the go tool skips, and those of the loaded
+// packages, which include the packages of the module they import. Packages of
+// other file systems have relative paths and are left out.
+func (g *Generator) watchDirs() []string {
+	var dirs []string
+	if g.inputDir != "" {
+		filepath.Walk(g.inputDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return nil
+			}
+			if path != g.inputDir && isIgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			if absPath, err := filepath.Abs(path); err == nil {
+				dirs = append(dirs, absPath)
+			}
+			return nil
+		})
+	}
+	for dir := range g.parser.loader.dirPaths {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// reports whether a file system event may change the generated code
+func isInputChange(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	if strings.HasSuffix(name, ".go") || name == "go.mod" {
+		return true
+	}
+	// a new directory may hold a new package, a removed one may have held one
+	if event.Has(fsnotify.Create) {
+		info, err := os.Stat(event.Name)
+		return err == nil && info.IsDir()
+	}
+	return event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+}
+
+// returns the stamps of the go files and go.mod files in the directories, and
+// of their subdirectories so that new packages are noticed
+func stampFiles(dirs map[string]bool) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() {
+				if !isIgnoredDir(name) {
+					stamps[filepath.Join(dir, name)] = fileStamp{}
+				}
+				continue
+			}
+			if !strings.HasSuffix(name, ".go") && name != "go.mod" {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			stamps[filepath.Join(dir, name)] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, stamp := range a {
+		other, ok := b[file]
+		if !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// generates zod schemas and the types inferred from them
+func (g *Generator) generateZod(enums []EnumInfo, structs []StructInfo) string {
+	var sb strings.Builder
+
+	g.imports = make(map[string]map[string]bool)
+	g.zodDeclared = make(map[*types.TypeName]bool)
+	g.zodRecursive = g.recursiveStructs()
+
+	// the declarations of namespaces are written after the others, so their
+	// schemas are not declared before the end of the file
+	declare := func(obj *types.TypeName, pkgPath string) {
+		if g.module != nil || g.namespaces[pkgPath] == "" {
+			g.zodDeclared[obj] = true
+		}
+	}
+	sb.WriteString(g.generateDeclarations(enums, structs, func(enumInfo EnumInfo) string {
+		code := g.generateZodEnum(enumInfo)
+		if enumInfo.GoType != nil {
+			declare(enumInfo.GoType.Obj(), enumInfo.PkgPath)
+		}
+		return code
+	}, func(structInfo StructInfo) string {
+		code := g.generateZodStruct(structInfo)
+		if structInfo.GoType != nil {
+			declare(structInfo.GoType.Obj(), structInfo.PkgPath)
+		}
+		return code
+	}))
+
+	header := "/* Do not change, this code is generated from Golang structs */\n\n"
+	header += "import { z } from \"zod\";\n"
+	header += g.generateImports() + "\n"
+
+	return header + sb.String()
+}
+
+// generates the zod schema of a struct, generic structs get a function that
+// takes the schemas of their type arguments and keep their interface
+func (g *Generator) generateZodStruct(structInfo StructInfo) string {
+	var sb strings.Builder
+
+	schema := ""
+	if structInfo.GoType != nil {
+		if tsType, ok := g.marshaledTypeToTS(structInfo.GoType); ok {
+			schema = zodFromTS(tsType)
+		}
+	}
+	if schema == "" {
+		schema = g.zodObject(structInfo, 0)
+	}
+
+	if structInfo.GoType != nil && structInfo.GoType.TypeParams().Len() > 0 {
+		params := structInfo.GoType.TypeParams()
+		typeParams := make([]string, 0, params.Len())
+		args := make([]string, 0, params.Len())
+		inferred := make([]string, 0, params.Len())
+		for i := 0; i < params.Len(); i++ {
+			name := params.At(i).Obj().Name()
+			typeParams = append(typeParams, name+" extends z.ZodTypeAny")
+			args = append(args, name+": "+name)
+			inferred = append(inferred, "z.infer<"+name+">")
+		}
+		// the type of a recursive schema can not be inferred from itself
+		returnType := ""
+		if g.zodRecursive[structInfo.GoType.Obj()] {
+			returnType = fmt.Sprintf(": z.ZodType<%s<%s>>", structInfo.Name, strings.Join(inferred, ", "))
+		}
+		sb.WriteString(g.generateStruct(structInfo))
+		sb.WriteString(fmt.Sprintf("export const %sSchema = <%s>(%s)%s => %s;\n", structInfo.Name, strings.Join(typeParams, ", "), strings.Join(args, ", "), returnType, schema))
+		return sb.String()
+	}
+
+	if structInfo.GoType != nil && g.zodRecursive[structInfo.GoType.Obj()] {
+		sb.WriteString(g.generateStruct(structInfo))
+		sb.WriteString(fmt.Sprintf("export const %[1]sSchema: z.ZodType<%[1]s> = %[2]s;\n", structInfo.Name, schema))
+		return sb.String()
+	}
+
+	sb.WriteString(tsDoc(structInfo.Doc, 0))
+	sb.WriteString(fmt.Sprintf("export const %sSchema = %s;\n", structInfo.Name, schema))
+	sb.WriteString(fmt.Sprintf("export type %[1]s = z.infer<typeof %[1]sSchema>;\n", structInfo.Name))
+	return sb.String()
+}
+
+// generates the zod schema of an enum
+func (g *Generator) generateZodEnum(enumInfo EnumInfo) string {
+	var sb strings.Builder
+
+	if tsType, ok := g.marshaledTypeToTS(enumInfo.GoType); ok {
+		sb.WriteString(tsDoc(enumInfo.Doc, 0))
+		sb.WriteString(fmt.Sprintf("export const %sSchema = %s;\n", enumInfo.Name, zodFromTS(tsType)))
+		sb.WriteString(fmt.Sprintf("export type %[1]s = z.infer<typeof %[1]sSchema>;\n", enumInfo.Name))
+		return sb.String()
+	}
+
+	if enumInfo.IsString && g.stringEnumStyle == StringEnumUnion {
+		sb.WriteString(tsDoc(enumInfo.Doc, 0))
+		sb.WriteString(fmt.Sprintf("export const %sSchema = z.enum([%s]);\n", enumInfo.Name, strings.Join(stringEnumLiterals(enumInfo), ", ")))
+		sb.WriteString(fmt.Sprintf("export type %[1]s = z.infer<typeof %[1]sSchema>;\n", enumInfo.Name))
+		return sb.String()
+	}
+
+	sb.WriteString(g.generateEnum(enumInfo))
+	sb.WriteString(fmt.Sprintf("export const %[1]sSchema = z.nativeEnum(%[1]s);\n", enumInfo.Name))
+	return sb.String()
+}
+
+// generates a z.object schema with the fields of a struct, embedded structs
+// are always promoted
+func (g *Generator) zodObject(structInfo StructInfo, indentLevel int) string {
+	var sb strings.Builder
+
+	indent := strings.Repeat("	", indentLevel)
+	sb.WriteString("z.object({\n")
+	for _, field := range g.promotedFields(structInfo) {
+		schema := g.goTypeToZod(field, indentLevel+1)
+		if field.IsReadonly {
+			schema += ".readonly()"
+		}
+		if field.IsOptional {
+			schema += ".optional()"
+		}
+
+		fieldName := field.Name
+		if field.JSONTag != "" {
+			fieldName = field.JSONTag
+		}
+
+		sb.WriteString(tsDoc(field.Doc, indentLevel+1))
+		sb.WriteString(fmt.Sprintf("%s	%s: %s,\n", indent, tsPropertyName(fieldName), schema))
+	}
+	sb.WriteString(indent + "})")
+
+	return sb.String()
+}
+
+// converts the go type of a field to a zod schema, like goTypeToTS
+func (g *Generator) goTypeToZod(field FieldInfo, indentLevel int) string {
+	if field.TSType != "" {
+		return zodFromTS(field.TSType)
+	}
+	if field.EmbeddedStruct != nil {
+		return g.zodObject(*field.EmbeddedStruct, indentLevel)
+	}
+	if tsType, ok := g.mapField(field); ok {
+		return zodFromTS(g.useTSType(tsType))
+	}
+
+	if g.isQuotedField(field) {
+		if field.IsPointer {
+			return "z.string().nullable()"
+		}
+		return "z.string()"
+	}
+
+	if schema, ok := g.validatedZod(field, indentLevel); ok {
+		return schema
+	}
+
+	return g.typeToZod(field.GoType, indentLevel)
+}
+
+// converts a go type to a zod schema, like typeToTS
+func (g *Generator) typeToZod(goType types.Type, indentLevel int) string {
+	return walkType(g, goType, func(c typeClass) string {
+		switch c.kind {
+		case kindFixed:
+			return zodFromTS(c.tsType)
+		case kindBasic:
+			return zodFromTS(basicTypeToTS(c.basic))
+		case kindPointer:
+			return g.typeToZod(c.elem, indentLevel) + ".nullable()"
+		case kindBytes:
+			return "z.string()"
+		case kindSlice, kindArray:
+			return "z.array(" + g.typeToZod(c.elem, indentLevel) + ")"
+		case kindMap:
+			// object keys are strings in json, integer keys are parsed as numbers
+			key := "z.string()"
+			if mapKeyTypeToTS(c.key) == "number" {
+				key = "z.coerce.number()"
+			}
+			return "z.record(" + key + ", " + g.typeToZod(c.elem, indentLevel) + ")"
+		case kindStruct:
+			return g.zodObject(*c.structInfo, indentLevel)
+		case kindStructRef:
+			schema := g.declarationName(c.structInfo.PkgPath, c.structInfo.Name+"Schema", true)
+			if args := c.named.TypeArgs(); args.Len() > 0 {
+				schemas := make([]string, 0, args.Len())
+				for i := 0; i < args.Len(); i++ {
+					schemas = append(schemas, g.typeToZod(args.At(i), indentLevel))
+				}
+				schema += "(" + strings.Join(schemas, ", ") + ")"
+			}
+			return g.zodReference(c.named, schema)
+		case kindEnumRef:
+			return g.zodReference(c.named, g.declarationName(c.enumInfo.PkgPath, c.enumInfo.Name+"Schema", true))
+		case kindInterface, kindRecursive:
+			return "z.any()"
+		case kindTypeParam:
+			return c.param.Obj().Name()
+		default:
+			return "z.unknown()"
+		}
+	})
+}
+
+// returns the schema of a parsed struct or enum, schemas declared further
+// down, in other modules or recursive ones are resolved lazily
+func (g *Generator) zodReference(named *types.Named, schema string) string {
+	if !g.zodDeclared[named.Origin().Obj()] {
+		return "z.lazy(() => " + schema + ")"
+	}
+	return schema
+}
+
+// converts a TypeScript type to a zod schema, types zod has no schema for
+// are checked at compile time only
+func zodFromTS(tsType string) string {
+	switch tsType {
+	case "string":
+		return "z.string()"
+	case "number":
+		return "z.number()"
+	case "boolean":
+		return "z.boolean()"
+	case "any":
+		return "z.any()"
+	case "unknown":
+		return "z.unknown()"
+	}
+	if inner, ok := strings.CutSuffix(tsType, " | null"); ok && !isUnionType(inner) {
+		return zodFromTS(inner) + ".nullable()"
+	}
+	return "z.custom<" + tsType + ">()"
+}
+
+// finds the structs that reference themselves, directly or through other
+// structs. TypeScript can not infer the type of their schemas, so they get
+// an interface to annotate the schema with.
+func (g *Generator) recursiveStructs() map[*types.TypeName]bool {
+	references := make(map[*types.TypeName][]*types.TypeName)
+	for _, structInfo := range g.parser.parseResult.Structs {
+		if structInfo.GoType == nil {
+			continue
+		}
+		obj := structInfo.GoType.Obj()
+		seen := make(map[types.Type]bool)
+		for _, field := range g.promotedFields(structInfo) {
+			g.collectStructs(field.GoType, seen, func(ref *types.TypeName) {
+				references[obj] = append(references[obj], ref)
+			})
+		}
+	}
+
+	recursive := make(map[*types.TypeName]bool)
+	for obj := range references {
+		visited := make(map[*types.TypeName]bool)
+		stack := append([]*types.TypeName(nil), references[obj]...)
+		for len(stack) > 0 {
+			ref := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if ref == obj {
+				recursive[obj] = true
+				break
+			}
+			if !visited[ref] {
+				visited[ref] = true
+				stack = append(stack, references[ref]...)
+			}
+		}
+	}
+	return recursive
+}
+
+// calls visit for every scanned struct a type refers to
+func (g *Generator) collectStructs(goType types.Type, seen map[types.Type]bool, visit func(*types.TypeName)) {
+	if seen[goType] {
+		return
+	}
+	seen[goType] = true
+
+	switch t := types.Unalias(goType).(type) {
+	case *types.Named:
+		if _, ok := g.parser.lookupStruct(t); ok {
+			visit(t.Origin().Obj())
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			g.collectStructs(t.TypeArgs().At(i), seen, visit)
+		}
+		if _, ok := g.parser.lookupStruct(t); !ok {
+			g.collectStructs(t.Underlying(), seen, visit)
+		}
+	case *types.Pointer:
+		g.collectStructs(t.Elem(), seen, visit)
+	case *types.Slice:
+		g.collectStructs(t.Elem(), seen, visit)
+	case *types.Array:
+		g.collectStructs(t.Elem(), seen, visit)
+	case *types.Map:
+		g.collectStructs(t.Elem(), seen, visit)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			g.collectStructs(t.Field(i).Type(), seen, visit)
+		}
+	}
+}